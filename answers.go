@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	policyAll = "all"
+	policyGo  = "go"
+)
+
+// PolicyPrompter upgrades every module whose kind of update the policy allows, without asking. It never excludes
+// versions.
+type PolicyPrompter struct {
+	Kinds      map[UpdateKind]bool
+	Directives bool
+}
+
+// NewPolicyPrompter parses a comma-separated list of update kinds to upgrade, such as "patch" or "patch,minor". "all"
+// allows every update except downgrades, and "go" allows raising the go and toolchain directives.
+func NewPolicyPrompter(policy string) (*PolicyPrompter, error) {
+	p := &PolicyPrompter{Kinds: map[UpdateKind]bool{}}
+
+	for _, name := range strings.Split(policy, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case policyAll:
+			for kind := UpdateKind(0); kind < numUpdateKinds; kind++ {
+				if kind != UpdateDowngrade {
+					p.Kinds[kind] = true
+				}
+			}
+		case policyGo:
+			p.Directives = true
+		default:
+			kind, ok := parseUpdateKind(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown update kind %q in policy %q", name, policy)
+			}
+			p.Kinds[kind] = true
+		}
+	}
+
+	return p, nil
+}
+
+func (p *PolicyPrompter) AskForUpgrades(modules []Module) (Choices, error) {
+	var choices Choices
+	for _, mod := range modules {
+		allowed := p.Kinds[mod.Update]
+		if mod.Directive != "" {
+			allowed = p.Directives
+		}
+		if allowed {
+			choices.Upgrades = addUpgrade(choices.Upgrades, mod)
+		}
+	}
+
+	fmt.Print(renderChoices(choices))
+	return choices, nil
+}
+
+// addUpgrade adds mod to upgrades unless another target of the same module was already chosen, as only one version
+// of a module can be required. Pseudo-versioned modules are offered several targets, of which the one go list found is
+// preferred over the labelled ones.
+func addUpgrade(upgrades []Module, mod Module) []Module {
+	for i, chosen := range upgrades {
+		if chosen.Name != mod.Name || chosen.Directive != mod.Directive {
+			continue
+		}
+		if chosen.Label != "" && mod.Label == "" {
+			upgrades[i] = mod
+		}
+		return upgrades
+	}
+
+	return append(upgrades, mod)
+}
+
+// Answer is a line of an answer script: a module to upgrade or, when Exclude is set, a version to mark as bad. An
+// empty Version matches whichever version is offered.
+type Answer struct {
+	Path    string
+	Version string
+	Exclude bool
+	Reason  string
+}
+
+func (a Answer) matches(mod Module) bool {
+	if a.Path != mod.Name && a.Path != mod.SourcePath() {
+		return false
+	}
+	return a.Version == "" || a.Version == mod.ToVersion.Original()
+}
+
+// ScriptPrompter answers with a script written in advance, so that gomo can run unattended.
+type ScriptPrompter struct {
+	Answers []Answer
+}
+
+func NewScriptPrompter(answers []Answer) *ScriptPrompter {
+	return &ScriptPrompter{
+		Answers: answers,
+	}
+}
+
+func (p *ScriptPrompter) AskForUpgrades(modules []Module) (Choices, error) {
+	var choices Choices
+	for _, mod := range modules {
+		for _, answer := range p.Answers {
+			if !answer.matches(mod) {
+				continue
+			}
+
+			if !answer.Exclude {
+				choices.Upgrades = addUpgrade(choices.Upgrades, mod)
+			} else if mod.Directive != "" {
+				return Choices{}, fmt.Errorf("the %s directive can't be excluded", mod.Directive)
+			} else {
+				choices.Exclusions = append(choices.Exclusions, Exclusion{Module: mod, Reason: answer.Reason})
+			}
+			break
+		}
+	}
+
+	fmt.Print(renderChoices(choices))
+	return choices, nil
+}
+
+// LoadAnswers reads an answer script from a file, or from stdin when path is "-".
+func LoadAnswers(path string) ([]Answer, error) {
+	if path == "-" {
+		return ReadAnswers(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening answers: %w", err)
+	}
+	defer f.Close()
+
+	return ReadAnswers(f)
+}
+
+// ReadAnswers parses an answer script. Each line is a module to upgrade, as <module> or <module>@<version> to only
+// upgrade to that version, or "exclude <module>@<version> [reason]" to mark a version as bad. Blank lines and lines
+// starting with # are ignored.
+func ReadAnswers(r io.Reader) ([]Answer, error) {
+	var answers []Answer
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		answer, err := parseAnswer(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing answers line %d: %w", number, err)
+		}
+		answers = append(answers, answer)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading answers: %w", err)
+	}
+
+	return answers, nil
+}
+
+func parseAnswer(line string) (Answer, error) {
+	fields := strings.Fields(line)
+
+	if fields[0] == "exclude" {
+		if len(fields) < 2 {
+			return Answer{}, fmt.Errorf("expected exclude <module>@<version> [reason], got %q", line)
+		}
+		path, version, err := parseModuleVersion(fields[1])
+		if err != nil {
+			return Answer{}, err
+		}
+		reason := strings.Join(fields[2:], " ")
+		if reason == "" {
+			reason = defaultExcludeReason
+		}
+		return Answer{Path: path, Version: version, Exclude: true, Reason: reason}, nil
+	}
+
+	if len(fields) > 1 {
+		return Answer{}, fmt.Errorf("expected <module> or <module>@<version>, got %q", line)
+	}
+	if !strings.Contains(fields[0], "@") {
+		return Answer{Path: fields[0]}, nil
+	}
+	path, version, err := parseModuleVersion(fields[0])
+	if err != nil {
+		return Answer{}, err
+	}
+	return Answer{Path: path, Version: version}, nil
+}
+
+// renderChoices lists the answers of a prompter that doesn't ask, so that unattended runs show what they did.
+func renderChoices(choices Choices) string {
+	var result strings.Builder
+	for _, mod := range choices.Upgrades {
+		fmt.Fprintf(&result, "Selected %s %s -> %s\n", mod.DisplayName(), mod.FromVersion, mod.ToVersion)
+	}
+	for _, exclusion := range choices.Exclusions {
+		fmt.Fprintf(&result, "Marked %s@%s as bad: %s\n", exclusion.Module.SourcePath(),
+			exclusion.Module.ToVersion.Original(), exclusion.Reason)
+	}
+	return result.String()
+}
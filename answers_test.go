@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func givenUpgrade(name string, from string, to string) Module {
+	fromVersion, toVersion := semver.MustParse(from), semver.MustParse(to)
+	return Module{
+		Name:        name,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Update:      classifyUpdate(fromVersion, toVersion),
+	}
+}
+
+func Test_NewPolicyPrompter_ParsesKinds(t *testing.T) {
+	p, err := NewPolicyPrompter("patch, minor")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[UpdateKind]bool{UpdatePatch: true, UpdateMinor: true}, p.Kinds)
+	assert.False(t, p.Directives)
+}
+
+func Test_NewPolicyPrompter_AllExcludesDowngradesAndDirectives(t *testing.T) {
+	p, err := NewPolicyPrompter("all")
+	require.NoError(t, err)
+
+	assert.True(t, p.Kinds[UpdateMajor])
+	assert.True(t, p.Kinds[UpdatePseudoVersion])
+	assert.False(t, p.Kinds[UpdateDowngrade])
+	assert.False(t, p.Directives)
+}
+
+func Test_NewPolicyPrompter_ReturnsErrorForUnknownKinds(t *testing.T) {
+	_, err := NewPolicyPrompter("patch,sometimes")
+
+	assert.EqualError(t, err, `unknown update kind "sometimes" in policy "patch,sometimes"`)
+}
+
+func Test_PolicyPrompter_SelectsAllowedUpdates(t *testing.T) {
+	patch := givenUpgrade("example.com/patch", "v1.0.0", "v1.0.1")
+	minor := givenUpgrade("example.com/minor", "v1.0.0", "v1.1.0")
+	major := givenUpgrade("example.com/major", "v1.0.0", "v2.0.0+incompatible")
+	directive := givenUpgrade(goDirective, "1.21.0", "1.21.5")
+	directive.Directive = goDirective
+
+	p, err := NewPolicyPrompter("patch,minor")
+	require.NoError(t, err)
+	choices, err := p.AskForUpgrades([]Module{directive, patch, minor, major})
+	require.NoError(t, err)
+	assert.Equal(t, Choices{Upgrades: []Module{patch, minor}}, choices)
+
+	p, err = NewPolicyPrompter("go")
+	require.NoError(t, err)
+	choices, err = p.AskForUpgrades([]Module{directive, patch, minor, major})
+	require.NoError(t, err)
+	assert.Equal(t, Choices{Upgrades: []Module{directive}}, choices)
+}
+
+func Test_Prompters_ChooseOneTargetPerModule(t *testing.T) {
+	const from = "v0.0.0-20200101000000-abcdefabcdef"
+	tagged := givenUpgrade("example.com/pseudo", from, "v0.1.0")
+	tagged.Label = firstTaggedReleaseLabel
+	latest := givenUpgrade("example.com/pseudo", from, "v0.0.0-20220101000000-fedcbafedcba")
+	latest.Label = latestCommitLabel
+	listed := givenUpgrade("example.com/pseudo", from, "v0.2.0")
+	modules := []Module{tagged, latest, listed}
+
+	policy, err := NewPolicyPrompter("all")
+	require.NoError(t, err)
+	choices, err := policy.AskForUpgrades(modules)
+	require.NoError(t, err)
+	assert.Equal(t, Choices{Upgrades: []Module{listed}}, choices)
+
+	choices, err = NewScriptPrompter([]Answer{{Path: "example.com/pseudo"}}).AskForUpgrades(modules)
+	require.NoError(t, err)
+	assert.Equal(t, Choices{Upgrades: []Module{listed}}, choices)
+
+	choices, err = NewScriptPrompter([]Answer{{Path: "example.com/pseudo", Version: "v0.1.0"}}).AskForUpgrades(modules)
+	require.NoError(t, err)
+	assert.Equal(t, Choices{Upgrades: []Module{tagged}}, choices)
+}
+
+func Test_ReadAnswers_ParsesScript(t *testing.T) {
+	script := `
+# upgrades
+example.com/any
+example.com/pinned@v1.2.3
+
+exclude example.com/bad@v2.0.0 leaks memory
+exclude example.com/worse@v3.0.0
+`
+
+	answers, err := ReadAnswers(strings.NewReader(script))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Answer{
+		{Path: "example.com/any"},
+		{Path: "example.com/pinned", Version: "v1.2.3"},
+		{Path: "example.com/bad", Version: "v2.0.0", Exclude: true, Reason: "leaks memory"},
+		{Path: "example.com/worse", Version: "v3.0.0", Exclude: true, Reason: defaultExcludeReason},
+	}, answers)
+}
+
+func Test_ReadAnswers_ReturnsErrorWithLineNumber(t *testing.T) {
+	for _, line := range []string{
+		"exclude example.com/bad",
+		"exclude",
+		"example.com/any extra",
+		"example.com/pinned@latest",
+	} {
+		_, err := ReadAnswers(strings.NewReader("example.com/any\n" + line + "\n"))
+
+		require.Error(t, err, line)
+		assert.Contains(t, err.Error(), "parsing answers line 2: ", line)
+	}
+}
+
+func Test_ScriptPrompter_AnswersMatchingModules(t *testing.T) {
+	anyVersion := givenUpgrade("example.com/any", "v1.0.0", "v1.1.0")
+	pinned := givenUpgrade("example.com/pinned", "v1.0.0", "v1.1.0")
+	bad := givenUpgrade("example.com/bad", "v1.0.0", "v2.0.0+incompatible")
+	unlisted := givenUpgrade("example.com/unlisted", "v1.0.0", "v1.0.1")
+	p := NewScriptPrompter([]Answer{
+		{Path: "example.com/any"},
+		{Path: "example.com/pinned", Version: "v1.2.3"},
+		{Path: "example.com/bad", Version: "v2.0.0+incompatible", Exclude: true, Reason: "leaks memory"},
+	})
+
+	choices, err := p.AskForUpgrades([]Module{anyVersion, pinned, bad, unlisted})
+	require.NoError(t, err)
+
+	assert.Equal(t, Choices{
+		Upgrades:   []Module{anyVersion},
+		Exclusions: []Exclusion{{Module: bad, Reason: "leaks memory"}},
+	}, choices)
+}
+
+func Test_ScriptPrompter_MatchesReplacementPaths(t *testing.T) {
+	replaced := givenUpgrade("example.com/original", "v1.0.0", "v1.1.0")
+	replaced.Replace = &Replacement{Path: "example.com/fork"}
+	p := NewScriptPrompter([]Answer{{Path: "example.com/fork"}})
+
+	choices, err := p.AskForUpgrades([]Module{replaced})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Module{replaced}, choices.Upgrades)
+}
+
+func Test_ScriptPrompter_ReturnsErrorForExcludedDirectives(t *testing.T) {
+	directive := givenUpgrade(goDirective, "1.21.0", "1.22.0")
+	directive.Directive = goDirective
+	p := NewScriptPrompter([]Answer{{Path: goDirective, Exclude: true}})
+
+	_, err := p.AskForUpgrades([]Module{directive})
+
+	assert.EqualError(t, err, "the go directive can't be excluded")
+}
+
+func Test_RenderChoices_ListsUpgradesAndExclusions(t *testing.T) {
+	upgrade := givenUpgrade("example.com/lib", "v1.0.0", "v1.1.0")
+	bad := givenUpgrade("example.com/bad", "v1.0.0", "v1.0.1")
+
+	result := renderChoices(Choices{
+		Upgrades:   []Module{upgrade},
+		Exclusions: []Exclusion{{Module: bad, Reason: "leaks memory"}},
+	})
+
+	assert.Equal(t, "Selected example.com/lib 1.0.0 -> 1.1.0\nMarked example.com/bad@v1.0.1 as bad: leaks memory\n", result)
+}
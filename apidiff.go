@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type APIChange struct {
+	Package     string
+	Identifier  string
+	Description string
+}
+
+func (c APIChange) String() string {
+	if c.Identifier == "" {
+		return fmt.Sprintf("%s: %s", c.Package, c.Description)
+	}
+	return fmt.Sprintf("%s.%s: %s", c.Package, c.Identifier, c.Description)
+}
+
+type apiObject struct {
+	Kind      string
+	Signature string
+}
+
+type packageAPI map[string]apiObject
+
+type moduleDownload struct {
+	Dir   string
+	Error string
+}
+
+type APIChecker struct {
+	Executor Executor
+	Dir      string
+	Env      []string
+	files    []*ast.File
+}
+
+func NewAPIChecker(executor Executor, dir string) *APIChecker {
+	return &APIChecker{
+		Executor: executor,
+		Dir:      dir,
+	}
+}
+
+// Check reports the incompatible changes between the current and target versions of a module that affect
+// identifiers referenced by the main module.
+func (c *APIChecker) Check(ctx context.Context, module Module) ([]APIChange, error) {
+	if module.FromVersion == nil || module.ToVersion == nil {
+		return nil, fmt.Errorf("unknown versions for %q", module.Name)
+	}
+
+	files, err := c.mainModuleFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	imported := importedPackages(files, module.Name)
+	if len(imported) == 0 {
+		return nil, nil
+	}
+
+	fromDir, err := c.downloadDir(ctx, module.SourcePath(), module.FromVersion.Original())
+	if err != nil {
+		return nil, err
+	}
+	toDir, err := c.downloadDir(ctx, module.SourcePath(), module.ToVersion.Original())
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []APIChange
+	for _, pkgPath := range imported {
+		rel := filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(pkgPath, module.Name), "/"))
+		oldAPI, pkgName, err := loadPackageAPI(filepath.Join(fromDir, rel))
+		if err != nil {
+			continue
+		}
+
+		newAPI, _, err := loadPackageAPI(filepath.Join(toDir, rel))
+		if err != nil {
+			changes = append(changes, APIChange{Package: pkgPath, Description: "package removed"})
+			continue
+		}
+
+		used := referencedIdentifiers(files, pkgPath, pkgName)
+		for _, change := range diffPackageAPI(oldAPI, newAPI) {
+			if used[strings.SplitN(change.Identifier, ".", 2)[0]] {
+				change.Package = pkgPath
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (c *APIChecker) downloadDir(ctx context.Context, modulePath string, version string) (string, error) {
+	result, err := c.Executor.Run(ctx, Command{
+		Name: "go",
+		Args: []string{"mod", "download", "-json", modulePath + "@" + version},
+		Dir:  c.Dir,
+		Env:  c.Env,
+	})
+	if err != nil {
+		return "", fmt.Errorf("downloading %s@%s: %w", modulePath, version, err)
+	}
+
+	var download moduleDownload
+	if err := json.Unmarshal([]byte(result.Stdout), &download); err != nil {
+		return "", fmt.Errorf("parsing download of %s@%s: %w", modulePath, version, err)
+	}
+	if download.Error != "" {
+		return "", fmt.Errorf("downloading %s@%s: %s", modulePath, version, download.Error)
+	}
+
+	return download.Dir, nil
+}
+
+func (c *APIChecker) mainModuleFiles() ([]*ast.File, error) {
+	if c.files != nil {
+		return c.files, nil
+	}
+
+	fset := token.NewFileSet()
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return skipDir(c.Dir, path, info)
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil
+		}
+		c.files = append(c.files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing main module: %w", err)
+	}
+
+	return c.files, nil
+}
+
+func skipDir(root string, path string, info os.FileInfo) error {
+	if path == root {
+		return nil
+	}
+
+	name := info.Name()
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return filepath.SkipDir
+	}
+	if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+		return filepath.SkipDir
+	}
+
+	return nil
+}
+
+func importedPackages(files []*ast.File, modulePath string) []string {
+	seen := map[string]bool{}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/") {
+				seen[importPath] = true
+			}
+		}
+	}
+
+	var result []string
+	for importPath := range seen {
+		result = append(result, importPath)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func referencedIdentifiers(files []*ast.File, pkgPath string, pkgName string) map[string]bool {
+	used := map[string]bool{}
+	for _, file := range files {
+		localName := ""
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || importPath != pkgPath {
+				continue
+			}
+			localName = pkgName
+			if spec.Name != nil {
+				localName = spec.Name.Name
+			}
+		}
+		if localName == "" || localName == "_" || localName == "." {
+			continue
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == localName {
+				used[selector.Sel.Name] = true
+			}
+			return true
+		})
+	}
+
+	return used
+}
+
+func loadPackageAPI(dir string) (packageAPI, string, error) {
+	fset := token.NewFileSet()
+	notTest := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+
+	pkgs, err := parser.ParseDir(fset, dir, notTest, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for name, pkg := range pkgs {
+		if strings.HasSuffix(name, "_test") || name == "main" {
+			continue
+		}
+
+		api := packageAPI{}
+		for _, file := range pkg.Files {
+			addFileAPI(api, file)
+		}
+		return api, name, nil
+	}
+
+	return nil, "", fmt.Errorf("no package found in %s", dir)
+}
+
+func addFileAPI(api packageAPI, file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			addFuncAPI(api, decl)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				addSpecAPI(api, decl.Tok, spec)
+			}
+		}
+	}
+}
+
+func addFuncAPI(api packageAPI, decl *ast.FuncDecl) {
+	if !decl.Name.IsExported() {
+		return
+	}
+
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		api[decl.Name.Name] = apiObject{Kind: "func", Signature: funcSignature(decl.Type)}
+		return
+	}
+
+	receiver := receiverTypeName(decl.Recv.List[0].Type)
+	if ast.IsExported(receiver) {
+		api[receiver+"."+decl.Name.Name] = apiObject{Kind: "method", Signature: funcSignature(decl.Type)}
+	}
+}
+
+func addSpecAPI(api packageAPI, tok token.Token, spec ast.Spec) {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		if spec.Name.IsExported() {
+			addTypeAPI(api, spec)
+		}
+	case *ast.ValueSpec:
+		kind := "var"
+		if tok == token.CONST {
+			kind = "const"
+		}
+		signature := ""
+		if spec.Type != nil {
+			signature = types.ExprString(spec.Type)
+		}
+		for _, name := range spec.Names {
+			if name.IsExported() {
+				api[name.Name] = apiObject{Kind: kind, Signature: signature}
+			}
+		}
+	}
+}
+
+func addTypeAPI(api packageAPI, spec *ast.TypeSpec) {
+	name := spec.Name.Name
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		api[name] = apiObject{Kind: "struct"}
+		for _, field := range typ.Fields.List {
+			for _, fieldName := range fieldNames(field) {
+				if ast.IsExported(fieldName) {
+					api[name+"."+fieldName] = apiObject{Kind: "field", Signature: types.ExprString(field.Type)}
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		api[name] = apiObject{Kind: "interface"}
+		for _, method := range typ.Methods.List {
+			funcType, ok := method.Type.(*ast.FuncType)
+			if !ok {
+				continue
+			}
+			for _, methodName := range method.Names {
+				api[name+"."+methodName.Name] = apiObject{Kind: "interface method", Signature: funcSignature(funcType)}
+			}
+		}
+	default:
+		api[name] = apiObject{Kind: "type", Signature: types.ExprString(spec.Type)}
+	}
+}
+
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		return names
+	}
+
+	return []string{receiverTypeName(field.Type)}
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+
+	if x, ok := genericReceiverType(expr); ok {
+		return receiverTypeName(x)
+	}
+	return ""
+}
+
+func funcSignature(funcType *ast.FuncType) string {
+	signature := "func(" + fieldListTypes(funcType.Params) + ")"
+	if funcType.Results == nil || len(funcType.Results.List) == 0 {
+		return signature
+	}
+
+	results := fieldListTypes(funcType.Results)
+	if len(funcType.Results.List) == 1 && len(funcType.Results.List[0].Names) <= 1 {
+		return signature + " " + results
+	}
+	return signature + " (" + results + ")"
+}
+
+func fieldListTypes(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+
+	var result []string
+	for _, field := range fields.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			result = append(result, types.ExprString(field.Type))
+		}
+	}
+
+	return strings.Join(result, ", ")
+}
+
+func diffPackageAPI(oldAPI, newAPI packageAPI) []APIChange {
+	var changes []APIChange
+	for key, oldObject := range oldAPI {
+		newObject, ok := newAPI[key]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Identifier: key, Description: "removed " + oldObject.Kind})
+		case oldObject.Kind != newObject.Kind:
+			changes = append(changes, APIChange{
+				Identifier:  key,
+				Description: fmt.Sprintf("changed from %s to %s", oldObject.Kind, newObject.Kind),
+			})
+		case oldObject.Signature != "" && oldObject.Signature != newObject.Signature:
+			changes = append(changes, APIChange{
+				Identifier:  key,
+				Description: fmt.Sprintf("changed %s from %s to %s", oldObject.Kind, oldObject.Signature, newObject.Signature),
+			})
+		}
+	}
+
+	for key, newObject := range newAPI {
+		if _, ok := oldAPI[key]; !ok && newObject.Kind == "interface method" {
+			changes = append(changes, APIChange{Identifier: key, Description: "added method to interface"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Identifier < changes[j].Identifier
+	})
+
+	return changes
+}
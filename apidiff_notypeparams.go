@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package main
+
+import "go/ast"
+
+// genericReceiverType finds nothing before Go 1.18, whose parser can't read type parameters.
+func genericReceiverType(expr ast.Expr) (ast.Expr, bool) {
+	return nil, false
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Check_ReportsIncompatibleChangesToReferencedIdentifiers(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "main"), map[string]string{
+		"main.go": `package main
+
+import (
+	"example.com/lib"
+	other "example.com/lib/sub"
+)
+
+func main() {
+	lib.Changed(1)
+	lib.Removed()
+	var _ lib.Handler
+	other.Unchanged()
+}
+`,
+	})
+	writeFiles(t, filepath.Join(root, "old"), map[string]string{
+		"lib.go": `package lib
+
+func Changed(a int) {}
+func Removed() {}
+func NotUsed() {}
+
+type Handler interface {
+	Handle()
+}
+`,
+		"sub/sub.go": "package sub\n\nfunc Unchanged() {}\n",
+	})
+	writeFiles(t, filepath.Join(root, "new"), map[string]string{
+		"lib.go": `package lib
+
+func Changed(a int, b string) error { return nil }
+
+type Handler interface {
+	Handle()
+	Close() error
+}
+`,
+		"sub/sub.go": "package sub\n\nfunc Unchanged() {}\n",
+	})
+
+	mockExecutor := &MockExecutor{
+		OutputsForArgs: map[string]string{
+			"mod download -json example.com/lib@v1.0.0": fmt.Sprintf(`{"Dir": %q}`, filepath.Join(root, "old")),
+			"mod download -json example.com/lib@v1.1.0": fmt.Sprintf(`{"Dir": %q}`, filepath.Join(root, "new")),
+		},
+	}
+	c := NewAPIChecker(mockExecutor, filepath.Join(root, "main"))
+
+	changes, err := c.Check(context.Background(), Module{
+		Name:        "example.com/lib",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []APIChange{
+		{Package: "example.com/lib", Identifier: "Changed", Description: "changed func from func(int) to func(int, string) error"},
+		{Package: "example.com/lib", Identifier: "Handler.Close", Description: "added method to interface"},
+		{Package: "example.com/lib", Identifier: "Removed", Description: "removed func"},
+	}, changes)
+}
+
+func Test_Check_SkipsModulesThatAreNotImported(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+	writeFiles(t, root, map[string]string{
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+	})
+	mockExecutor := &MockExecutor{}
+	c := NewAPIChecker(mockExecutor, root)
+
+	changes, err := c.Check(context.Background(), Module{
+		Name:        "example.com/lib",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, changes)
+	assert.Empty(t, mockExecutor.RunCalls)
+}
+
+func Test_Check_ReturnsDownloadErrors(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+	writeFiles(t, root, map[string]string{
+		"main.go": "package main\n\nimport \"example.com/lib\"\n\nfunc main() { lib.Do() }\n",
+	})
+	mockExecutor := &MockExecutor{CommandOutput: `{"Error": "unknown revision v1.0.0"}`}
+	c := NewAPIChecker(mockExecutor, root)
+
+	_, err := c.Check(context.Background(), Module{
+		Name:        "example.com/lib",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	})
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "downloading example.com/lib@v1.0.0: unknown revision v1.0.0")
+}
+
+func Test_DiffPackageAPI_ReportsChangedStructFieldsAndKinds(t *testing.T) {
+	oldAPI := loadAPIFromSource(t, `package lib
+
+type Config struct {
+	Name    string
+	Timeout int
+}
+
+type Mode int
+`)
+	newAPI := loadAPIFromSource(t, `package lib
+
+type Config struct {
+	Name string
+	Timeout float64
+}
+
+type Mode struct{}
+`)
+
+	changes := diffPackageAPI(oldAPI, newAPI)
+
+	assert.Equal(t, []APIChange{
+		{Identifier: "Config.Timeout", Description: "changed field from int to float64"},
+		{Identifier: "Mode", Description: "changed from type to struct"},
+	}, changes)
+}
+
+func Test_FuncSignature_FormatsParametersAndResults(t *testing.T) {
+	api := loadAPIFromSource(t, `package lib
+
+func A(a, b int, opts ...string) (n int, err error) { return 0, nil }
+func B() (result string) { return "" }
+`)
+
+	assert.Equal(t, "func(int, int, ...string) (int, error)", api["A"].Signature)
+	assert.Equal(t, "func() string", api["B"].Signature)
+}
+
+func loadAPIFromSource(t *testing.T, source string) packageAPI {
+	file, err := parser.ParseFile(token.NewFileSet(), "lib.go", source, 0)
+	require.NoError(t, err)
+
+	api := packageAPI{}
+	addFileAPI(api, file)
+	return api
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gomo")
+	require.NoError(t, err)
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "go/ast"
+
+// genericReceiverType returns the type of a receiver with several type parameters, such as Map[K, V].
+func genericReceiverType(expr ast.Expr) (ast.Expr, bool) {
+	if expr, ok := expr.(*ast.IndexListExpr); ok {
+		return expr.X, true
+	}
+	return nil, false
+}
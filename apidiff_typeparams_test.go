@@ -0,0 +1,42 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffPackageAPI_ReportsChangedMethodsOfGenericTypes(t *testing.T) {
+	oldAPI := loadAPIFromSource(t, `package lib
+
+type Box[T any] struct{ v T }
+
+func (b *Box[T]) Get() T { return b.v }
+
+type Map[K comparable, V any] struct{}
+
+func (m *Map[K, V]) Get(key K) V { var v V; return v }
+func (m Map[K, V]) Len() int { return 0 }
+`)
+	newAPI := loadAPIFromSource(t, `package lib
+
+type Box[T any] struct{ v T }
+
+func (b *Box[T]) Get() (T, bool) { return b.v, true }
+
+type Map[K comparable, V any] struct{}
+
+func (m *Map[K, V]) Get(key K, fallback V) V { return fallback }
+`)
+
+	changes := diffPackageAPI(oldAPI, newAPI)
+
+	assert.ElementsMatch(t, []APIChange{
+		{Identifier: "Box.Get", Description: "changed method from func() T to func() (T, bool)"},
+		{Identifier: "Map.Get", Description: "changed method from func(K) V to func(K, V) V"},
+		{Identifier: "Map.Len", Description: "removed method"},
+	}, changes)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Binary is an executable installed with go install, identified by the build info the go command embeds in it.
+type Binary struct {
+	Name          string
+	Package       string
+	Module        string
+	ModuleVersion string
+}
+
+func runBin(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("gomo bin", flag.ContinueOnError)
+	simple := flags.Bool("simple", false, "use a simple multi-select prompt instead of the full-screen interface")
+	prerelease := flags.Bool("prerelease", false, "offer prerelease versions as upgrade candidates")
+	dryRun := flags.Bool("dry-run", false, "print the go install commands instead of running them")
+	verbose := flags.Bool("verbose", false, "print the go commands gomo runs and their output as they run")
+	timeout := flags.Duration("timeout", defaultCommandTimeout,
+		"how long each go command may run for before it is cancelled, or 0 for no limit")
+	auto := flags.String("auto", "",
+		"upgrade without asking, choosing updates of these comma-separated kinds (patch, minor, major, ..., all)")
+	refresh := flags.Bool("refresh", false, "check every cached lookup with its source instead of trusting it")
+	answers := flags.String("answers", "",
+		"upgrade without asking, choosing the modules listed in this answer file, or - to read them from stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *auto != "" && *answers != "" {
+		return fmt.Errorf("auto and answers can't be used together")
+	}
+	p, err := newPrompter(*simple, *auto, *answers)
+	if err != nil {
+		return err
+	}
+
+	executorOptions := []CommandExecutorOption{WithCommandTimeout(*timeout)}
+	if *verbose {
+		executorOptions = append(executorOptions, WithStreamOutput(os.Stderr))
+	}
+	cmdExecutor := NewCommandExecutor(executorOptions...)
+	goEnv, err := getGoEnv(ctx, cmdExecutor, "", nil, "GOBIN", "GOPATH", "GOPROXY", "GOMODCACHE",
+		"GONOPROXY")
+	if err != nil {
+		return err
+	}
+
+	binaries, err := readBinaries(ctx, cmdExecutor, binDir(goEnv["GOBIN"], goEnv["GOPATH"]))
+	if err != nil {
+		return err
+	}
+	if len(binaries) == 0 {
+		fmt.Println("No binaries installed with go install were found")
+		return nil
+	}
+
+	var proxyClient HTTPClient = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	if cacheDir, err := defaultCacheDir(); err == nil {
+		proxyClient = NewCachingHTTPClient(proxyClient, cacheDir, *refresh)
+	}
+	proxy := NewProxyClient(proxyClient, goEnv["GOPROXY"], modCacheDir(goEnv["GOMODCACHE"], goEnv["GOPATH"]),
+		WithRetries(proxyRetries, proxyRetryDelay),
+		WithNoProxy(goEnv["GONOPROXY"]),
+	)
+	var progress *Progress
+	if !*verbose && isTerminal(os.Stderr) {
+		progress = NewProgress(os.Stderr)
+	}
+	d := NewDiscoverer(
+		WithExecutor(cmdExecutor),
+		WithProxy(proxy),
+		WithConfig(Config{Prerelease: *prerelease}),
+		WithModuleQueries(binaryQueries(binaries)...),
+		WithProgress(progress),
+	)
+
+	progress.Start()
+	modules, err := d.GetModules(ctx)
+	progress.Stop()
+	if err != nil {
+		return fmt.Errorf("getting modules: %w", err)
+	}
+	for _, warning := range d.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if len(modules) == 0 {
+		fmt.Println("No binaries can be upgraded")
+		return nil
+	}
+
+	choices, err := p.AskForUpgrades(modules)
+	if err != nil {
+		return fmt.Errorf("asking for which binaries to upgrade: %w", err)
+	}
+	if len(choices.Exclusions) > 0 {
+		fmt.Println("Exclusions are recorded in go.mod, so they are ignored for binaries")
+	}
+	if len(choices.Upgrades) == 0 {
+		fmt.Println("No modules selected")
+		return nil
+	}
+
+	if *dryRun {
+		for _, args := range installCommands(binaries, choices.Upgrades) {
+			fmt.Printf("Would run go %s %s\n", args[0], args[1])
+		}
+		return nil
+	}
+	return installBinaries(ctx, cmdExecutor, binaries, choices.Upgrades)
+}
+
+// binDir is where go install puts binaries: GOBIN, or the bin directory of the first GOPATH entry.
+func binDir(gobin string, gopath string) string {
+	if gobin != "" {
+		return gobin
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "bin")
+}
+
+// readBinaries reads the build info of every executable in dir with go version -m, skipping files that weren't built
+// by the go command from a versioned module.
+func readBinaries(ctx context.Context, executor Executor, dir string) ([]Binary, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	result, err := executor.Run(ctx, Command{Name: "go", Args: []string{"version", "-m", dir}})
+	if err != nil {
+		return nil, fmt.Errorf("reading build info of the binaries in %s: %w", dir, err)
+	}
+
+	var binaries []Binary
+	for _, binary := range parseBuildInfo(result.Stdout) {
+		// go version walks the directory, but go install only writes to its top level.
+		if filepath.Dir(binary.Name) != filepath.Clean(dir) {
+			continue
+		}
+		if binary.Module == "" || binary.ModuleVersion == "" || binary.ModuleVersion == "(devel)" {
+			continue
+		}
+		binary.Name = filepath.Base(binary.Name)
+		binaries = append(binaries, binary)
+	}
+
+	return binaries, nil
+}
+
+// parseBuildInfo reads the output of go version -m, in which each file's line is followed by tab-separated lines such
+// as "\tpath\t<package>" and "\tmod\t<module>\t<version>\t<sum>". Binaries are named by their file paths.
+func parseBuildInfo(output string) []Binary {
+	var binaries []Binary
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			if colon := strings.LastIndex(line, ": "); colon >= 0 {
+				binaries = append(binaries, Binary{Name: line[:colon]})
+			}
+			continue
+		}
+		if len(binaries) == 0 {
+			continue
+		}
+
+		binary := &binaries[len(binaries)-1]
+		fields := strings.Split(line[1:], "\t")
+		switch {
+		case fields[0] == "path" && len(fields) >= 2:
+			binary.Package = fields[1]
+		case fields[0] == "mod" && len(fields) >= 3:
+			binary.Module, binary.ModuleVersion = fields[1], fields[2]
+		}
+	}
+
+	return binaries
+}
+
+func binaryQueries(binaries []Binary) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, binary := range binaries {
+		query := binary.Module + "@" + binary.ModuleVersion
+		if !seen[query] {
+			seen[query] = true
+			result = append(result, query)
+		}
+	}
+
+	return result
+}
+
+// installCommands returns the arguments to go install for every binary built from an upgraded module.
+func installCommands(binaries []Binary, modules []Module) [][]string {
+	var result [][]string
+	for _, module := range modules {
+		for _, binary := range binaries {
+			if binary.Module != module.Name || binary.ModuleVersion != module.FromVersion.Original() {
+				continue
+			}
+			target := binary.Package
+			if module.ToVersion != nil {
+				target += "@" + module.ToVersion.Original()
+			}
+			result = append(result, []string{"install", target})
+		}
+	}
+
+	return result
+}
+
+func installBinaries(ctx context.Context, executor Executor, binaries []Binary, modules []Module) error {
+	for _, args := range installCommands(binaries, modules) {
+		if _, err := executor.Run(ctx, Command{Name: "go", Args: args}); err != nil {
+			return fmt.Errorf("installing %s: %w", args[1], err)
+		}
+		fmt.Printf("Installed %s\n", args[1])
+	}
+
+	return nil
+}
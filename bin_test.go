@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BinDir_PrefersGobinOverGopath(t *testing.T) {
+	assert.Equal(t, "/gobin", binDir("/gobin", "/gopath"))
+	assert.Equal(t, filepath.Join("/first", "bin"), binDir("", "/first"+string(os.PathListSeparator)+"/second"))
+}
+
+func Test_ReadBinaries_SkipsFilesWithoutBuildInfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-bin")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "script"), []byte("#!/bin/sh\n"), 0755))
+
+	binaries, err := readBinaries(context.Background(), NewCommandExecutor(), dir)
+	require.NoError(t, err)
+
+	assert.Empty(t, binaries)
+}
+
+func Test_GetModules_ListsModuleQueries(t *testing.T) {
+	binaries := []Binary{
+		{Name: "a", Package: "example.com/tools/cmd/a", Module: "example.com/tools", ModuleVersion: "v1.0.0"},
+		{Name: "b", Package: "example.com/tools/cmd/b", Module: "example.com/tools", ModuleVersion: "v1.0.0"},
+	}
+	mockExecutor := MockExecutor{CommandOutput: "==START==example.com/tools,v1.0.0,v1.2.0,,,==END=="}
+	d := NewDiscoverer(
+		WithExecutor(&mockExecutor),
+		WithModuleQueries(binaryQueries(binaries)...),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.2.0", modules[0].ToVersion.Original())
+	assert.Equal(t, "list -m -u -e -f "+queryTemplate+" example.com/tools@v1.0.0", mockExecutor.RunCalls[0].Args)
+}
+
+func Test_InstallBinaries_ReinstallsEveryBinaryOfUpgradedModules(t *testing.T) {
+	binaries := []Binary{
+		{Name: "a", Package: "example.com/tools/cmd/a", Module: "example.com/tools", ModuleVersion: "v1.0.0"},
+		{Name: "b", Package: "example.com/tools/cmd/b", Module: "example.com/tools", ModuleVersion: "v1.0.0"},
+		{Name: "c", Package: "example.com/other", Module: "example.com/other", ModuleVersion: "v0.1.0"},
+	}
+	modules := []Module{{
+		Name:        "example.com/tools",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.2.0"),
+	}}
+	mockExecutor := MockExecutor{}
+
+	err := installBinaries(context.Background(), &mockExecutor, binaries, modules)
+	require.NoError(t, err)
+
+	assert.Equal(t, []RunCall{
+		{Command: "go", Args: "install example.com/tools/cmd/a@v1.2.0"},
+		{Command: "go", Args: "install example.com/tools/cmd/b@v1.2.0"},
+	}, mockExecutor.RunCalls)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const bitbucketAPIHost = "api.bitbucket.org"
+
+type BitbucketProvider struct {
+	HTTPClient HTTPClient
+}
+
+func NewBitbucketProvider(client HTTPClient) *BitbucketProvider {
+	return &BitbucketProvider{
+		HTTPClient: client,
+	}
+}
+
+func (p *BitbucketProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	return repoChangelogNotes(repo, module, func(path string) (string, error) {
+		req, err := newGetRequest(ctx, fmt.Sprintf("https://%s/2.0/repositories/%s/src/HEAD/%s", bitbucketAPIHost, repo.Path, path), nil)
+		if err != nil {
+			return "", err
+		}
+		return getText(p.HTTPClient, req)
+	})
+}
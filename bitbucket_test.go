@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BitbucketProvider_ReturnsChangelogExcerpt(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "## v1.1.0\n- new\n## v1.0.0\n- old\n", nil)
+	p := NewBitbucketProvider(mockClient)
+	module := Module{
+		Name:        "bitbucket.org/team/repo",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+
+	notes, err := p.ReleaseNotes(context.Background(), Repository{Forge: ForgeBitbucket, Host: "bitbucket.org", Path: "team/repo"}, module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://api.bitbucket.org/2.0/repositories/team/repo/src/HEAD/CHANGELOG.md", calls[0].URL.String())
+}
+
+func Test_BitbucketProvider_ReadsChangelogOfNestedModulesThenRoot(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(200, "## v1.1.0\n- new\n## v1.0.0\n- old\n", nil),
+	)
+	p := NewBitbucketProvider(mockClient)
+	module := Module{
+		Name:        "bitbucket.org/team/repo/sub",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+	repo := Repository{Forge: ForgeBitbucket, Host: "bitbucket.org", Path: "team/repo", Subdir: "sub"}
+
+	notes, err := p.ReleaseNotes(context.Background(), repo, module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://api.bitbucket.org/2.0/repositories/team/repo/src/HEAD/sub/CHANGELOG.md", calls[0].URL.String())
+	assert.Equal(t, "https://api.bitbucket.org/2.0/repositories/team/repo/src/HEAD/CHANGELOG.md", calls[1].URL.String())
+}
+
+func Test_BitbucketProvider_ReturnsErrorWhenChangelogMissing(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(404, "", nil)
+	p := NewBitbucketProvider(mockClient)
+
+	_, err := p.ReleaseNotes(context.Background(), Repository{Forge: ForgeBitbucket, Host: "bitbucket.org", Path: "team/repo"}, Module{})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "fetching CHANGELOG.md: unexpected status from api.bitbucket.org")
+}
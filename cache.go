@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kinds of cached responses, each kept for its own time to live.
+const (
+	cacheVersions = "versions"
+	cacheModules  = "modules"
+	cacheSearches = "searches"
+	cacheForges   = "forges"
+)
+
+var cacheTTLs = map[string]time.Duration{
+	// New versions are published at any time, so version lists go stale quickly.
+	cacheVersions: time.Hour,
+	// The files of a module version never change once published.
+	cacheModules:  30 * 24 * time.Hour,
+	cacheSearches: 24 * time.Hour,
+	cacheForges:   6 * time.Hour,
+}
+
+// defaultCacheDir is gomo's directory in the user cache directory, such as ~/.cache/gomo on Linux.
+func defaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gomo"), nil
+}
+
+type cacheEntry struct {
+	Key    string      `json:"key"`
+	URL    string      `json:"url"`
+	Stored time.Time   `json:"stored"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// CachingHTTPClient keeps successful responses to GET requests on disk. Fresh responses are served without a request,
+// and stale ones are revalidated with If-None-Match or If-Modified-Since when the server sent an ETag or Last-Modified.
+// Refresh treats every cached response as stale.
+type CachingHTTPClient struct {
+	Client  HTTPClient
+	Dir     string
+	Refresh bool
+}
+
+func NewCachingHTTPClient(client HTTPClient, dir string, refresh bool) *CachingHTTPClient {
+	return &CachingHTTPClient{
+		Client:  client,
+		Dir:     dir,
+		Refresh: refresh,
+	}
+}
+
+func (c *CachingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.Client.Do(req)
+	}
+
+	kind, key := cacheKey(req)
+	path := filepath.Join(c.Dir, kind, hashCacheKey(key))
+	entry, body, cached := c.read(path, key)
+	if cached && !c.Refresh && now().Sub(entry.Stored) < cacheTTLs[kind] {
+		return cachedResponse(req, entry, body), nil
+	}
+
+	if cached {
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cached && res.StatusCode == http.StatusNotModified:
+		res.Body.Close()
+		entry.Stored = now()
+		c.write(path, entry, body)
+		return cachedResponse(req, entry, body), nil
+	case res.StatusCode == http.StatusOK:
+		content, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading response from %s: %w", req.URL, err)
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(content))
+		c.write(path, cacheEntry{Key: key, URL: req.URL.String(), Stored: now(), Header: res.Header}, content)
+	}
+
+	return res, nil
+}
+
+// read returns the cached response for key, if there is one. Entries that can't be read are treated as missing.
+func (c *CachingHTTPClient) read(path string, key string) (cacheEntry, []byte, bool) {
+	content, err := ioutil.ReadFile(path + ".json")
+	if err != nil {
+		return cacheEntry{}, nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.Key != key {
+		return cacheEntry{}, nil, false
+	}
+
+	body, err := ioutil.ReadFile(path + ".body")
+	if err != nil {
+		return cacheEntry{}, nil, false
+	}
+
+	return entry, body, true
+}
+
+// write stores a response, ignoring failures as the cache only saves time. The body is written first, so that an
+// entry is never read with another response's body.
+func (c *CachingHTTPClient) write(path string, entry cacheEntry, body []byte) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	if err := writeFileAtomic(path+".body", body); err != nil {
+		return
+	}
+	_ = writeFileAtomic(path+".json", content)
+}
+
+func cachedResponse(req *http.Request, entry cacheEntry, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     entry.Header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// cacheKey classifies a request and names what it fetches. Module proxy requests are keyed by module@version, as every
+// proxy serves the same files for a version, and other requests by URL.
+func cacheKey(req *http.Request) (string, string) {
+	if at := strings.Index(req.URL.Path, "/@v/"); at >= 0 {
+		modulePath, file := strings.TrimPrefix(req.URL.Path[:at], "/"), req.URL.Path[at+len("/@v/"):]
+		if file == "list" {
+			return cacheVersions, modulePath
+		}
+		return cacheModules, modulePath + "@" + file
+	}
+	if strings.HasSuffix(req.URL.Path, "/@latest") {
+		return cacheVersions, strings.TrimPrefix(req.URL.Path, "/")
+	}
+	if req.URL.Host == githubAPIHost && strings.HasPrefix(req.URL.Path, "/search/") {
+		return cacheSearches, req.URL.String()
+	}
+	return cacheForges, req.URL.String()
+}
+
+func hashCacheKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func runCache(args []string) error {
+	if len(args) == 0 || args[0] != "clean" {
+		return fmt.Errorf("usage: gomo cache clean [--cache-dir directory]")
+	}
+
+	flags := flag.NewFlagSet("gomo cache clean", flag.ContinueOnError)
+	dir := flags.String("cache-dir", "", "the cache directory to remove, instead of gomo's directory in the user cache")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		var err error
+		*dir, err = defaultCacheDir()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(*dir); err != nil {
+		return fmt.Errorf("removing %s: %w", *dir, err)
+	}
+
+	fmt.Printf("Removed %s\n", *dir)
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func givenCacheDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gomo-cache")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func getThroughCache(t *testing.T, client HTTPClient, rawURL string) (int, string) {
+	req, err := newGetRequest(context.Background(), rawURL, nil)
+	require.NoError(t, err)
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
+
+func Test_CachingHTTPClient_ServesFreshResponsesFromDisk(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\n", nil)
+	dir := givenCacheDir(t)
+
+	getThroughCache(t, NewCachingHTTPClient(mockClient, dir, false), "https://proxy.example.com/example.com/mod/@v/list")
+	mockClient.GivenErrorIsReturned(assert.AnError)
+	status, body := getThroughCache(t, NewCachingHTTPClient(mockClient, dir, false),
+		"https://other.example.com/example.com/mod/@v/list")
+
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "v1.0.0\n", body)
+	assert.Len(t, mockClient.GetCalls(), 1)
+}
+
+func Test_CachingHTTPClient_RevalidatesStaleResponsesWithETag(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\n", http.Header{"Etag": []string{`"abc"`}})
+	client := NewCachingHTTPClient(mockClient, givenCacheDir(t), false)
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+
+	givenNow(t, time.Date(2020, 6, 10, 2, 0, 0, 0, time.UTC))
+	mockClient.GivenResponseIsReturned(304, "", nil)
+	status, body := getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "v1.0.0\n", body)
+
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, `"abc"`, calls[1].Header.Get("If-None-Match"))
+
+	// The revalidated response is fresh again.
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+	assert.Len(t, mockClient.GetCalls(), 2)
+}
+
+func Test_CachingHTTPClient_ReplacesStaleResponses(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\n", http.Header{"Last-Modified": []string{"Wed, 10 Jun 2020 00:00:00 GMT"}})
+	client := NewCachingHTTPClient(mockClient, givenCacheDir(t), false)
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+
+	givenNow(t, time.Date(2020, 6, 10, 2, 0, 0, 0, time.UTC))
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\nv1.1.0\n", nil)
+	_, body := getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+	assert.Equal(t, "v1.0.0\nv1.1.0\n", body)
+	assert.Equal(t, "Wed, 10 Jun 2020 00:00:00 GMT", mockClient.GetCalls()[1].Header.Get("If-Modified-Since"))
+
+	_, body = getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+	assert.Equal(t, "v1.0.0\nv1.1.0\n", body)
+	assert.Len(t, mockClient.GetCalls(), 2)
+}
+
+func Test_CachingHTTPClient_KeepsModuleFilesLongerThanVersionLists(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "{}", nil)
+	client := NewCachingHTTPClient(mockClient, givenCacheDir(t), false)
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/v1.0.0.info")
+
+	givenNow(t, time.Date(2020, 6, 20, 0, 0, 0, 0, time.UTC))
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/v1.0.0.info")
+
+	assert.Len(t, mockClient.GetCalls(), 1)
+}
+
+func Test_CachingHTTPClient_RefreshRevalidatesFreshResponses(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "{}", http.Header{"Etag": []string{`"abc"`}})
+	dir := givenCacheDir(t)
+	getThroughCache(t, NewCachingHTTPClient(mockClient, dir, false), "https://proxy.example.com/example.com/mod/@v/v1.0.0.info")
+
+	mockClient.GivenResponseIsReturned(304, "", nil)
+	_, body := getThroughCache(t, NewCachingHTTPClient(mockClient, dir, true),
+		"https://proxy.example.com/example.com/mod/@v/v1.0.0.info")
+
+	assert.Equal(t, "{}", body)
+	assert.Len(t, mockClient.GetCalls(), 2)
+}
+
+func Test_CachingHTTPClient_DoesNotCacheFailures(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(404, "not found", nil)
+	client := NewCachingHTTPClient(mockClient, givenCacheDir(t), false)
+
+	status, _ := getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+	assert.Equal(t, 404, status)
+	getThroughCache(t, client, "https://proxy.example.com/example.com/mod/@v/list")
+
+	assert.Len(t, mockClient.GetCalls(), 2)
+}
+
+func Test_CacheKey_ClassifiesRequests(t *testing.T) {
+	for rawURL, expected := range map[string][2]string{
+		"https://proxy.golang.org/github.com/!foo/bar/@v/list":          {cacheVersions, "github.com/!foo/bar"},
+		"https://proxy.golang.org/github.com/!foo/bar/@latest":          {cacheVersions, "github.com/!foo/bar/@latest"},
+		"https://proxy.golang.org/github.com/!foo/bar/@v/v1.0.0.zip":    {cacheModules, "github.com/!foo/bar@v1.0.0.zip"},
+		"https://api.github.com/search/code?q=filename:CHANGELOG":       {cacheSearches, "https://api.github.com/search/code?q=filename:CHANGELOG"},
+		"https://api.github.com/repos/foo/bar/releases":                 {cacheForges, "https://api.github.com/repos/foo/bar/releases"},
+		"https://raw.githubusercontent.com/foo/bar/v1.0.0/CHANGELOG.md": {cacheForges, "https://raw.githubusercontent.com/foo/bar/v1.0.0/CHANGELOG.md"},
+	} {
+		req, err := newGetRequest(context.Background(), rawURL, nil)
+		require.NoError(t, err)
+
+		kind, key := cacheKey(req)
+
+		assert.Equal(t, expected, [2]string{kind, key}, rawURL)
+	}
+}
+
+func Test_RunCache_CleanRemovesCacheDir(t *testing.T) {
+	dir := filepath.Join(givenCacheDir(t), "gomo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, cacheVersions), 0755))
+
+	err := runCache([]string{"clean", "--cache-dir", dir})
+	require.NoError(t, err)
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_RunCache_ReturnsErrorForUnknownCommands(t *testing.T) {
+	assert.EqualError(t, runCache([]string{"purge"}), "usage: gomo cache clean [--cache-dir directory]")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type ChangelogSection struct {
+	Version *semver.Version
+	Heading string
+	Body    string
+}
+
+var (
+	changelogHeadingRegex = regexp.MustCompile(`^#{1,4}\s+(.*)$`)
+	changelogVersionRegex = regexp.MustCompile(`v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+)
+
+// parseChangelog splits a markdown changelog into one section per version heading. It understands
+// Keep a Changelog ("## [1.2.3] - 2020-01-01"), plain ("## v1.2.3") and release-please
+// ("## [1.2.3](https://.../compare/v1.2.2...v1.2.3) (2020-01-01)") headings.
+func parseChangelog(content string) []ChangelogSection {
+	var sections []ChangelogSection
+	var current *ChangelogSection
+	var body []string
+
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			sections = append(sections, *current)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		version := versionFromHeading(line)
+		if version == nil {
+			body = append(body, line)
+			continue
+		}
+
+		flush()
+		current = &ChangelogSection{
+			Version: version,
+			Heading: strings.TrimSpace(line),
+		}
+	}
+	flush()
+
+	return sections
+}
+
+func versionFromHeading(line string) *semver.Version {
+	heading := changelogHeadingRegex.FindStringSubmatch(line)
+	if heading == nil {
+		return nil
+	}
+
+	match := changelogVersionRegex.FindString(heading[1])
+	if match == "" {
+		return nil
+	}
+
+	version, err := semver.NewVersion(match)
+	if err != nil {
+		return nil
+	}
+	return version
+}
+
+// extractChangelogExcerpt returns the sections of a changelog for versions in the range (from, to].
+func extractChangelogExcerpt(content string, from, to *semver.Version) string {
+	var result []string
+	for _, section := range parseChangelog(content) {
+		if from != nil && !section.Version.GreaterThan(from) {
+			continue
+		}
+		if to != nil && section.Version.GreaterThan(to) {
+			continue
+		}
+
+		result = append(result, section.Heading)
+		if section.Body != "" {
+			result = append(result, section.Body)
+		}
+	}
+
+	return strings.Join(result, "\n\n")
+}
+
+func changelogNotes(filename string, content string, module Module) (string, error) {
+	excerpt := extractChangelogExcerpt(content, module.FromVersion, module.ToVersion)
+	if excerpt == "" {
+		return "", fmt.Errorf("no %s entries between %s and %s", filename, module.FromVersion, module.ToVersion)
+	}
+
+	return excerpt, nil
+}
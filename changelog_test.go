@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseChangelog_ParsesKeepAChangelogFormat(t *testing.T) {
+	content := `# Changelog
+
+## [Unreleased]
+
+## [1.1.0] - 2020-03-01
+### Added
+- a new feature
+
+## [1.0.0] - 2020-01-01
+### Fixed
+- a bug
+`
+	sections := parseChangelog(content)
+
+	require.Len(t, sections, 2)
+	assert.Equal(t, semver.MustParse("1.1.0"), sections[0].Version)
+	assert.Equal(t, "## [1.1.0] - 2020-03-01", sections[0].Heading)
+	assert.Equal(t, "### Added\n- a new feature", sections[0].Body)
+	assert.Equal(t, semver.MustParse("1.0.0"), sections[1].Version)
+	assert.Equal(t, "### Fixed\n- a bug", sections[1].Body)
+}
+
+func Test_ParseChangelog_ParsesVersionHeadings(t *testing.T) {
+	content := "## v2.0.0\n* breaking\n\n## v1.9.3\n* fix\n"
+
+	sections := parseChangelog(content)
+
+	require.Len(t, sections, 2)
+	assert.Equal(t, semver.MustParse("v2.0.0"), sections[0].Version)
+	assert.Equal(t, "* breaking", sections[0].Body)
+	assert.Equal(t, semver.MustParse("v1.9.3"), sections[1].Version)
+}
+
+func Test_ParseChangelog_ParsesReleasePleaseHeadings(t *testing.T) {
+	content := `### [1.2.3](https://github.com/foo/bar/compare/v1.2.2...v1.2.3) (2020-04-01)
+
+### Bug Fixes
+
+* something
+`
+	sections := parseChangelog(content)
+
+	require.Len(t, sections, 1)
+	assert.Equal(t, semver.MustParse("1.2.3"), sections[0].Version)
+	assert.Equal(t, "### Bug Fixes\n\n* something", sections[0].Body)
+}
+
+func Test_ExtractChangelogExcerpt_ReturnsSectionsBetweenVersions(t *testing.T) {
+	content := "## v1.3.0\nthree\n## v1.2.0\ntwo\n## v1.1.0\none\n## v1.0.0\nzero\n"
+
+	excerpt := extractChangelogExcerpt(content, semver.MustParse("1.0.0"), semver.MustParse("1.2.0"))
+
+	assert.Equal(t, "## v1.2.0\n\ntwo\n\n## v1.1.0\n\none", excerpt)
+}
+
+func Test_ExtractChangelogExcerpt_ReturnsEmptyWhenNoSectionsMatch(t *testing.T) {
+	content := "## v1.0.0\nzero\n"
+
+	excerpt := extractChangelogExcerpt(content, semver.MustParse("1.0.0"), semver.MustParse("1.1.0"))
+
+	assert.Empty(t, excerpt)
+}
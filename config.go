@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	configFilename     = ".gomo.json"
+	userConfigFilename = "config.json"
+)
+
+type Config struct {
+	Prerelease bool                    `json:"prerelease,omitempty"`
+	GoReleases string                  `json:"goReleases,omitempty"`
+	Modules    map[string]ModuleConfig `json:"modules,omitempty"`
+}
+
+// UserConfig holds the settings of the user running gomo, such as credentials, which unlike Config must not be
+// committed to a project's repository.
+type UserConfig struct {
+	GithubToken string `json:"githubToken,omitempty"`
+}
+
+type ModuleConfig struct {
+	Prerelease *bool           `json:"prerelease,omitempty"`
+	Excludes   []ExcludeConfig `json:"excludes,omitempty"`
+}
+
+type ExcludeConfig struct {
+	Version string `json:"version"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func loadConfig(path string) (Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func defaultUserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "gomo", userConfigFilename), nil
+}
+
+func loadUserConfig(path string) (UserConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return UserConfig{}, nil
+	}
+	if err != nil {
+		return UserConfig{}, fmt.Errorf("reading user config %s: %w", path, err)
+	}
+
+	var config UserConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return UserConfig{}, fmt.Errorf("parsing user config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// AllowsPrerelease reports whether prerelease versions of a module may be offered, with per-module settings taking
+// precedence over the global one.
+func (c Config) AllowsPrerelease(modulePath string) bool {
+	if module, ok := c.Modules[modulePath]; ok && module.Prerelease != nil {
+		return *module.Prerelease
+	}
+	return c.Prerelease
+}
+
+func saveConfig(path string, config Config) error {
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+
+	return writeFileAtomic(path, append(content, '\n'))
+}
+
+func (c Config) IsExcluded(modulePath string, version string) bool {
+	for _, exclude := range c.Modules[modulePath].Excludes {
+		if exclude.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) AddExclude(modulePath string, version string, reason string) {
+	if c.Modules == nil {
+		c.Modules = map[string]ModuleConfig{}
+	}
+
+	module := c.Modules[modulePath]
+	for i, exclude := range module.Excludes {
+		if exclude.Version == version {
+			module.Excludes[i].Reason = reason
+			c.Modules[modulePath] = module
+			return
+		}
+	}
+
+	module.Excludes = append(module.Excludes, ExcludeConfig{Version: version, Reason: reason})
+	c.Modules[modulePath] = module
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadConfig_ReturnsEmptyConfigWhenFileIsMissing(t *testing.T) {
+	config, err := loadConfig(filepath.Join(os.TempDir(), "does-not-exist", configFilename))
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{}, config)
+}
+
+func Test_LoadConfig_ParsesModuleSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, configFilename)
+	content := `{"prerelease": true, "modules": {"example.com/stable": {"prerelease": false}}}`
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	config, err := loadConfig(path)
+	require.NoError(t, err)
+
+	assert.True(t, config.AllowsPrerelease("example.com/other"))
+	assert.False(t, config.AllowsPrerelease("example.com/stable"))
+}
+
+func Test_LoadConfig_ReturnsErrorForInvalidJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, configFilename)
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+
+	_, err = loadConfig(path)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "parsing config")
+}
+
+func Test_LoadUserConfig_ReadsGithubToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, userConfigFilename)
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"githubToken": "token"}`), 0600))
+
+	config, err := loadUserConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, UserConfig{GithubToken: "token"}, config)
+
+	config, err = loadUserConfig(filepath.Join(dir, "does-not-exist", userConfigFilename))
+	require.NoError(t, err)
+	assert.Equal(t, UserConfig{}, config)
+}
+
+func Test_AllowsPrerelease_OptsInPerModule(t *testing.T) {
+	enabled := true
+	config := Config{Modules: map[string]ModuleConfig{"example.com/rc": {Prerelease: &enabled}}}
+
+	assert.True(t, config.AllowsPrerelease("example.com/rc"))
+	assert.False(t, config.AllowsPrerelease("example.com/other"))
+}
+
+func Test_AddExclude_RecordsAndUpdatesReasons(t *testing.T) {
+	var config Config
+
+	config.AddExclude("example.com/a", "v1.1.0", "first reason")
+	config.AddExclude("example.com/a", "v1.1.0", "second reason")
+
+	assert.True(t, config.IsExcluded("example.com/a", "v1.1.0"))
+	assert.False(t, config.IsExcluded("example.com/a", "v1.2.0"))
+	assert.Equal(t, []ExcludeConfig{{Version: "v1.1.0", Reason: "second reason"}}, config.Modules["example.com/a"].Excludes)
+}
+
+func Test_SaveConfig_RoundTrips(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var config Config
+	config.AddExclude("example.com/a", "v1.1.0", "broken")
+	path := filepath.Join(dir, configFilename)
+	require.NoError(t, saveConfig(path, config))
+
+	loaded, err := loadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, config, loaded)
+}
@@ -1,64 +1,113 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 )
 
 type Module struct {
-	Name         string
-	FromVersion  *semver.Version
-	ToVersion    *semver.Version
-	PatchUpgrade bool
-	MinorUpgrade bool
+	Name        string
+	FromVersion *semver.Version
+	ToVersion   *semver.Version
+	FromTime    time.Time
+	ToTime      time.Time
+	Update      UpdateKind
+	Label       string
+	Replace     *Replacement
+	Directive   string
+	Tool        bool
+	Indirect    bool
+	RequiresGo  string
+	Changelog   string
+	APIChanges  []APIChange
+}
+
+// Replacement is the target of a replace directive. Version is nil for filesystem replacements.
+type Replacement struct {
+	Path    string
+	Version *semver.Version
+}
+
+// SourcePath is the path of the module whose code is actually used, which differs from Name when it is replaced.
+func (m Module) SourcePath() string {
+	if m.Replace != nil {
+		return m.Replace.Path
+	}
+	return m.Name
+}
+
+func (m Module) DisplayName() string {
+	if m.Replace != nil {
+		return fmt.Sprintf("%s => %s", m.Name, m.Replace.Path)
+	}
+	return m.Name
 }
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+type ReleaseNotesProvider interface {
+	ReleaseNotes(ctx context.Context, module Module) (string, error)
+}
+
 type Discoverer struct {
-	Executor        Executor
-	HTTPClient      HTTPClient
-	ModuleRegex     string
-	ListCommand     string
-	ListCommandArgs []string
+	Executor              Executor
+	ReleaseNotesProviders []ReleaseNotesProvider
+	Proxy                 *ProxyClient
+	Cooldown              time.Duration
+	Config                Config
+	Dir                   string
+	Env                   []string
+	GoReleases            GoReleaseSource
+	ModFile               string
+	QueryProxy            bool
+	Workers               int
+	Progress              *Progress
+	Warnings              []string
+	ModuleRegex           string
+	ListCommand           string
+	ListCommandArgs       []string
 }
 
 const (
-	template           = "'{{if (and (not (or .Main .Indirect)) .Update)}}==START=={{.Path}},{{.Version}},{{.Update.Version}}==END=={{end}}'"
-	expectedNumMatches = 4
-	changelogFilename  = "CHANGELOG.md"
+	template      = "'{{if not .Main}}" + moduleTemplate + "{{end}}'"
+	queryTemplate = "'{{if .Error}}" + errorMarker + "{{.Path}} {{printf \"%q\" .Error.Err}}" +
+		"{{else if not .Main}}" + moduleTemplate + "{{end}}'"
+	moduleTemplate = "==START=={{.Path}},{{.Version}},{{with .Update}}{{.Version}}{{end}}," +
+		"{{with .Time}}{{.Format \"2006-01-02T15:04:05Z07:00\"}}{{end}}," +
+		"{{with .Update}}{{with .Time}}{{.Format \"2006-01-02T15:04:05Z07:00\"}}{{end}}{{end}}," +
+		"{{with .Replace}}{{.Path}}@{{.Version}}{{end}}==END=={{if .Indirect}}" + indirectMarker + "{{end}}"
+	indirectMarker     = "==INDIRECT=="
+	errorMarker        = "==ERROR=="
+	expectedNumMatches = 7
+
+	firstTaggedReleaseLabel = "first tagged release containing the commit"
+	latestCommitLabel       = "latest commit on the default branch"
 )
 
-type DiscovererOption func(*Discoverer)
+var now = time.Now
 
-type GithubFileSearchResponse struct {
-	TotalCount int    `json:"total_count"`
-	Items      []Item `json:"items"`
-}
-
-type Item struct {
-	Name    string `json:"name"`
-	Path    string `json:"path"`
-	HTMLURL string `json:"html_url"`
-}
+type DiscovererOption func(*Discoverer)
 
 func NewDiscoverer(options ...DiscovererOption) *Discoverer {
 	d := &Discoverer{
 		Executor:    nil,
-		ModuleRegex: "==START==(.+),(.+),(.+)==END==",
+		ModuleRegex: "==START==([^,]+),([^,]+),([^,]*),([^,]*),([^,]*),([^,]*)==END==",
 		ListCommand: "go",
 		ListCommandArgs: []string{
 			"list", "-m", "-u", "-f", template, "all",
 		},
-		HTTPClient: nil,
+		Workers: defaultWorkers,
 	}
 
 	for _, option := range options {
@@ -74,14 +123,86 @@ func WithExecutor(executor Executor) DiscovererOption {
 	}
 }
 
-func WithHTTPClient(client HTTPClient) DiscovererOption {
+func WithReleaseNotesProviders(providers ...ReleaseNotesProvider) DiscovererOption {
+	return func(d *Discoverer) {
+		d.ReleaseNotesProviders = providers
+	}
+}
+
+func WithProxy(proxy *ProxyClient) DiscovererOption {
+	return func(d *Discoverer) {
+		d.Proxy = proxy
+	}
+}
+
+func WithCooldown(cooldown time.Duration) DiscovererOption {
+	return func(d *Discoverer) {
+		d.Cooldown = cooldown
+	}
+}
+
+func WithConfig(config Config) DiscovererOption {
+	return func(d *Discoverer) {
+		d.Config = config
+	}
+}
+
+// WithDir runs the go command in dir, to discover the upgrades of the module there.
+func WithDir(dir string) DiscovererOption {
+	return func(d *Discoverer) {
+		d.Dir = dir
+	}
+}
+
+// WithEnv sets KEY=value overrides of the environment the go command runs with, such as GOFLAGS or GOPROXY.
+func WithEnv(env ...string) DiscovererOption {
+	return func(d *Discoverer) {
+		d.Env = env
+	}
+}
+
+func WithGoReleases(source GoReleaseSource) DiscovererOption {
+	return func(d *Discoverer) {
+		d.GoReleases = source
+	}
+}
+
+// WithMainModFile sets the go.mod of the main module, whose go and toolchain directives are checked against Go
+// releases and the requirements of upgraded modules.
+func WithMainModFile(path string) DiscovererOption {
+	return func(d *Discoverer) {
+		d.ModFile = path
+	}
+}
+
+// WithModuleQueries lists the given module@version queries instead of the requirements of the main module. Queries
+// that fail, such as those for private modules, are skipped with a warning rather than failing the others.
+func WithModuleQueries(queries ...string) DiscovererOption {
+	return func(d *Discoverer) {
+		d.ListCommandArgs = append([]string{"list", "-m", "-u", "-e", "-f", queryTemplate}, queries...)
+	}
+}
+
+// WithProxyQueries finds updates by querying the module proxy directly, looking up to workers modules up at a time,
+// rather than with go list -u.
+func WithProxyQueries(workers int) DiscovererOption {
+	return func(d *Discoverer) {
+		d.QueryProxy = true
+		d.Workers = workers
+	}
+}
+
+func WithProgress(progress *Progress) DiscovererOption {
 	return func(d *Discoverer) {
-		d.HTTPClient = client
+		d.Progress = progress
 	}
 }
 
-func (d *Discoverer) GetModules() ([]Module, error) {
-	listOutput, err := d.listModules()
+func (d *Discoverer) GetModules(ctx context.Context) ([]Module, error) {
+	d.Warnings = nil
+
+	d.Progress.Status("listing modules", 0)
+	listOutput, err := d.listModules(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("listing modules: %w", err)
 	}
@@ -91,83 +212,360 @@ func (d *Discoverer) GetModules() ([]Module, error) {
 		return nil, fmt.Errorf("parsing modules: %w", err)
 	}
 
+	modules, err = d.dropIndirectModules(modules)
+	if err != nil {
+		return nil, fmt.Errorf("finding tool dependencies: %w", err)
+	}
+
+	if d.QueryProxy {
+		modules, err = d.addProxyUpdates(ctx, modules)
+		if err != nil {
+			return nil, fmt.Errorf("looking up updates: %w", err)
+		}
+	}
+
+	d.Progress.Status("checking replacements, prereleases and pseudo-versions", 0)
+	modules, err = d.addReplacementTargets(ctx, modules)
+	if err != nil {
+		return nil, fmt.Errorf("checking replacements: %w", err)
+	}
+
+	modules, err = d.addPrereleaseTargets(ctx, modules)
+	if err != nil {
+		return nil, fmt.Errorf("finding prereleases: %w", err)
+	}
+
+	modules = d.addPseudoVersionTargets(ctx, modules)
+
+	if d.Cooldown > 0 {
+		modules, err = d.applyCooldown(ctx, modules)
+		if err != nil {
+			return nil, fmt.Errorf("applying cooldown: %w", err)
+		}
+	}
+
+	if d.ModFile != "" {
+		modules, err = d.addGoDirectives(ctx, modules)
+		if err != nil {
+			return nil, fmt.Errorf("checking go directives: %w", err)
+		}
+		if err := d.markToolDependencies(modules); err != nil {
+			return nil, fmt.Errorf("finding tool dependencies: %w", err)
+		}
+	}
+
 	return modules, nil
 }
 
-func getChangelogFromGithubSearchResult(searchResponse *GithubFileSearchResponse) (string, error) {
-	for _, item := range searchResponse.Items {
-		if item.Path == changelogFilename {
-			return item.HTMLURL, nil
+// applyCooldown replaces targets published within the cooldown with the newest older version, dropping modules
+// that have no such version.
+func (d *Discoverer) applyCooldown(ctx context.Context, modules []Module) ([]Module, error) {
+	cutoff := now().Add(-d.Cooldown)
+
+	var result []Module
+	for _, module := range modules {
+		if module.ToTime.IsZero() || !module.ToTime.After(cutoff) {
+			result = append(result, module)
+			continue
+		}
+		if module.Label != "" {
+			continue
+		}
+
+		cooled, ok, err := d.newestVersionBefore(ctx, module, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("finding versions of %q: %w", module.Name, err)
+		}
+		if ok {
+			result = append(result, cooled)
 		}
 	}
 
-	return "", fmt.Errorf("failed to find a root level %s", changelogFilename)
+	return result, nil
 }
 
-func (d *Discoverer) GetChangelog(module Module) (string, error) {
-	githubResp, err := d.searchGithubForChangelog(module)
-	if err != nil {
-		return "", err
+func (d *Discoverer) newestVersionBefore(ctx context.Context, module Module, cutoff time.Time) (Module, bool, error) {
+	if d.Proxy == nil {
+		return Module{}, false, fmt.Errorf("no module proxy configured")
 	}
 
-	result, err := getChangelogFromGithubSearchResult(githubResp)
+	versions, err := d.Proxy.Versions(ctx, module.SourcePath())
 	if err != nil {
-		return module.Name, err
+		return Module{}, false, err
 	}
 
-	return result, err
+	var candidates []*semver.Version
+	for _, v := range versions {
+		version, err := semver.NewVersion(v)
+		if err != nil || (version.Prerelease() != "" && !d.Config.AllowsPrerelease(module.Name)) ||
+			d.Config.IsExcluded(module.SourcePath(), v) {
+			continue
+		}
+		if version.GreaterThan(module.FromVersion) && version.LessThan(module.ToVersion) {
+			candidates = append(candidates, version)
+		}
+	}
+	sort.Sort(sort.Reverse(semver.Collection(candidates)))
+
+	for _, candidate := range candidates {
+		info, err := d.Proxy.Info(ctx, module.SourcePath(), candidate.Original())
+		if err != nil {
+			return Module{}, false, err
+		}
+		if info.Time.After(cutoff) {
+			continue
+		}
+
+		module.ToVersion = candidate
+		module.ToTime = info.Time
+		module.Update = classifyUpdate(module.FromVersion, candidate)
+		return module, true, nil
+	}
+
+	return Module{}, false, nil
 }
 
-func (d *Discoverer) searchGithubForChangelog(module Module) (*GithubFileSearchResponse, error) {
-	repo, err := getGithubRepoFromModule(module)
-	if err != nil {
-		return nil, err
+// addReplacementTargets offers updates of the replacement module in place of the replaced one, as go get can't
+// change which code is used while the replace directive exists. Filesystem replacements are skipped with a warning.
+func (d *Discoverer) addReplacementTargets(ctx context.Context, modules []Module) ([]Module, error) {
+	var result []Module
+	for _, module := range modules {
+		if module.Replace == nil {
+			result = append(result, module)
+			continue
+		}
+		if module.Replace.Version == nil {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("skipping %s, which is replaced by the local directory %s",
+				module.Name, module.Replace.Path))
+			continue
+		}
+		if d.Proxy == nil {
+			return nil, fmt.Errorf("no module proxy configured")
+		}
+		if d.Proxy.IsPrivate(module.Replace.Path) {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("skipping %s, whose replacement %s matches GONOPROXY",
+				module.Name, module.Replace.Path))
+			continue
+		}
+
+		versions, err := d.Proxy.Versions(ctx, module.Replace.Path)
+		if err != nil {
+			return nil, fmt.Errorf("listing versions of %q: %w", module.Replace.Path, err)
+		}
+
+		newest := module.Replace.Version
+		for _, v := range versions {
+			version, err := semver.NewVersion(v)
+			if err != nil || version.Prerelease() != "" || d.Config.IsExcluded(module.Replace.Path, v) {
+				continue
+			}
+			if version.GreaterThan(newest) {
+				newest = version
+			}
+		}
+		if newest == module.Replace.Version {
+			continue
+		}
+
+		info, err := d.Proxy.Info(ctx, module.Replace.Path, newest.Original())
+		if err != nil {
+			return nil, fmt.Errorf("reading info for %s@%s: %w", module.Replace.Path, newest.Original(), err)
+		}
+
+		module.FromVersion = module.Replace.Version
+		module.FromTime = time.Time{}
+		module.ToVersion = newest
+		module.ToTime = info.Time
+		module.Update = classifyUpdate(module.FromVersion, newest)
+		result = append(result, module)
+	}
+
+	return result, nil
+}
+
+// addPrereleaseTargets replaces the target of modules that opted into prereleases with the newest version, using
+// semver ordering so that v1.3.0-rc.1 is newer than v1.2.0 but older than v1.3.0.
+func (d *Discoverer) addPrereleaseTargets(ctx context.Context, modules []Module) ([]Module, error) {
+	for i, module := range modules {
+		if module.Replace != nil || !d.Config.AllowsPrerelease(module.Name) {
+			continue
+		}
+		if d.Proxy == nil {
+			return nil, fmt.Errorf("no module proxy configured")
+		}
+		if d.Proxy.IsPrivate(module.Name) {
+			continue
+		}
+
+		versions, err := d.Proxy.Versions(ctx, module.Name)
+		if err != nil {
+			return nil, fmt.Errorf("listing versions of %q: %w", module.Name, err)
+		}
+
+		newest := module.ToVersion
+		if newest == nil {
+			newest = module.FromVersion
+		}
+		for _, v := range versions {
+			version, err := semver.NewVersion(v)
+			if err == nil && version.GreaterThan(newest) && !d.Config.IsExcluded(module.Name, v) {
+				newest = version
+			}
+		}
+		if newest == module.ToVersion || newest == module.FromVersion {
+			continue
+		}
+
+		info, err := d.Proxy.Info(ctx, module.Name, newest.Original())
+		if err != nil {
+			return nil, fmt.Errorf("reading info for %s@%s: %w", module.Name, newest.Original(), err)
+		}
+
+		modules[i].ToVersion = newest
+		modules[i].ToTime = info.Time
+		modules[i].Update = classifyUpdate(module.FromVersion, newest)
 	}
 
-	u := &url.URL{
-		Scheme:   "https",
-		Host:     "api.github.com",
-		Path:     "/search/code",
-		RawQuery: fmt.Sprintf("q=repo:%s%sfilename:CHANGELOG.md", repo, "+"),
+	return modules, nil
+}
+
+// addPseudoVersionTargets offers modules pinned to a pseudo-version the first tagged release published after their
+// commit and the latest commit on the default branch, alongside the update reported by go list.
+func (d *Discoverer) addPseudoVersionTargets(ctx context.Context, modules []Module) []Module {
+	var result []Module
+	for _, module := range modules {
+		if module.ToVersion != nil {
+			result = append(result, module)
+		}
+		if module.Replace != nil || !isPseudoVersion(module.FromVersion) {
+			continue
+		}
+
+		if tagged, ok := d.firstTaggedRelease(ctx, module); ok {
+			result = append(result, tagged)
+		}
+		if latest, ok := d.latestCommit(ctx, module); ok {
+			result = append(result, latest)
+		}
 	}
-	res, err := d.HTTPClient.Do(&http.Request{
-		URL: u,
-	})
+
+	return result
+}
+
+func (d *Discoverer) firstTaggedRelease(ctx context.Context, module Module) (Module, bool) {
+	commitTime, ok := pseudoVersionTime(module.FromVersion)
+	if !ok || d.Proxy == nil {
+		return Module{}, false
+	}
+
+	versions, err := d.Proxy.Versions(ctx, module.Name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make a request for changelog: %w", err)
+		return Module{}, false
 	}
-	defer res.Body.Close()
 
-	var githubResp GithubFileSearchResponse
-	decoder := json.NewDecoder(res.Body)
-	if err = decoder.Decode(&githubResp); err != nil {
-		return nil, fmt.Errorf("unexpected response from github API: %w", err)
+	var tags []*semver.Version
+	for _, v := range versions {
+		version, err := semver.NewVersion(v)
+		if err != nil || version.Prerelease() != "" || !version.GreaterThan(module.FromVersion) ||
+			d.Config.IsExcluded(module.Name, v) {
+			continue
+		}
+		tags = append(tags, version)
 	}
+	sort.Sort(semver.Collection(tags))
 
-	return &githubResp, nil
+	for _, tag := range tags {
+		info, err := d.Proxy.Info(ctx, module.Name, tag.Original())
+		if err != nil {
+			return Module{}, false
+		}
+		if info.Time.Before(commitTime) {
+			continue
+		}
+		if module.ToVersion != nil && tag.Equal(module.ToVersion) {
+			return Module{}, false
+		}
+
+		return newTargetModule(module, tag, info.Time, firstTaggedReleaseLabel), true
+	}
+
+	return Module{}, false
 }
 
-func getGithubRepoFromModule(module Module) (string, error) {
-	const githubRepoRegex = "github.com/(.+)"
-	re, err := regexp.Compile(githubRepoRegex)
-	if err != nil {
-		return "", err
+// defaultBranchQueries are tried in order, as module proxies resolve branch names but not HEAD.
+var defaultBranchQueries = []string{"HEAD", "main", "master"}
+
+func (d *Discoverer) latestCommit(ctx context.Context, module Module) (Module, bool) {
+	var info ModuleInfo
+	found := false
+	for _, query := range defaultBranchQueries {
+		result, err := d.Executor.Run(ctx, d.command("go", "list", "-m", "-json", module.Name+"@"+query))
+		if err == nil && json.Unmarshal([]byte(result.Stdout), &info) == nil && info.Version != "" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Module{}, false
 	}
 
-	matches := re.FindStringSubmatch(module.Name)
-	if len(matches) != 2 {
-		return "", fmt.Errorf("unable to parse module name")
+	version, err := semver.NewVersion(info.Version)
+	if err != nil || !version.GreaterThan(module.FromVersion) {
+		return Module{}, false
+	}
+	if module.ToVersion != nil && version.Equal(module.ToVersion) {
+		return Module{}, false
 	}
 
-	return matches[1], nil
+	return newTargetModule(module, version, info.Time, latestCommitLabel), true
 }
 
-func (d *Discoverer) listModules() (string, error) {
-	output, err := d.Executor.Run(d.ListCommand, d.ListCommandArgs...)
+func newTargetModule(module Module, to *semver.Version, toTime time.Time, label string) Module {
+	return Module{
+		Name:        module.Name,
+		FromVersion: module.FromVersion,
+		ToVersion:   to,
+		FromTime:    module.FromTime,
+		ToTime:      toTime,
+		Update:      classifyUpdate(module.FromVersion, to),
+		Label:       label,
+	}
+}
+
+func (d *Discoverer) GetReleaseNotes(ctx context.Context, module Module) (string, error) {
+	var errs []string
+	for _, provider := range d.ReleaseNotesProviders {
+		notes, err := provider.ReleaseNotes(ctx, module)
+		if err == nil {
+			return notes, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	return "", fmt.Errorf("no release notes found for %q: %s", module.Name, strings.Join(errs, "; "))
+}
+
+func (d *Discoverer) command(name string, args ...string) Command {
+	return Command{Name: name, Args: args, Dir: d.Dir, Env: d.Env}
+}
+
+func (d *Discoverer) listModules(ctx context.Context) (string, error) {
+	args := d.ListCommandArgs
+	if d.QueryProxy {
+		// Without -u, go list only reads the build list, leaving updates to addProxyUpdates.
+		args = nil
+		for _, arg := range d.ListCommandArgs {
+			if arg != "-u" {
+				args = append(args, arg)
+			}
+		}
+	}
+
+	result, err := d.Executor.Run(ctx, d.command(d.ListCommand, args...))
 	if err != nil {
-		return "", fmt.Errorf("running '%s %s': %w", d.ListCommand, d.ListCommandArgs, err)
+		return "", fmt.Errorf("running '%s %s': %w", d.ListCommand, args, err)
 	}
-	return output, nil
+	return result.Stdout, nil
 }
 
 func (d *Discoverer) parseModules(listOutput string) ([]Module, error) {
@@ -182,11 +580,18 @@ func (d *Discoverer) parseModules(listOutput string) ([]Module, error) {
 		if isInvalidModuleLine(line) {
 			continue
 		}
+		if strings.Contains(line, errorMarker) {
+			d.Warnings = append(d.Warnings, parseModuleError(line))
+			continue
+		}
 
 		m, err := extractModule(line, re)
 		if err != nil {
 			return nil, err
 		}
+		if !d.QueryProxy && !d.mayHaveUpdates(m) {
+			continue
+		}
 
 		modules = append(modules, m)
 	}
@@ -194,6 +599,27 @@ func (d *Discoverer) parseModules(listOutput string) ([]Module, error) {
 	return modules, nil
 }
 
+// mayHaveUpdates reports whether a module has an update, or may have one that later steps look for.
+func (d *Discoverer) mayHaveUpdates(m Module) bool {
+	return m.ToVersion != nil || m.Replace != nil || isPseudoVersion(m.FromVersion) || d.Config.AllowsPrerelease(m.Name)
+}
+
+// parseModuleError turns the line go list -e prints for a module it failed to look up into a warning.
+func parseModuleError(line string) string {
+	line = strings.Trim(line, "'")
+	line = line[strings.Index(line, errorMarker)+len(errorMarker):]
+	modulePath, quoted := line, ""
+	if space := strings.Index(line, " "); space >= 0 {
+		modulePath, quoted = line[:space], line[space+1:]
+	}
+	message, err := strconv.Unquote(quoted)
+	if err != nil {
+		message = quoted
+	}
+
+	return fmt.Sprintf("skipping %s, which couldn't be looked up: %s", modulePath, message)
+}
+
 func isInvalidModuleLine(line string) bool {
 	if line == "''" {
 		return true
@@ -215,22 +641,67 @@ func extractModule(moduleLine string, regex *regexp.Regexp) (Module, error) {
 		return Module{}, fmt.Errorf("parsing from version %q: %w", from, err)
 	}
 
-	to, err := semver.NewVersion(matches[3])
+	var to *semver.Version
+	if matches[3] != "" {
+		to, err = semver.NewVersion(matches[3])
+		if err != nil {
+			return Module{}, fmt.Errorf("parsing to version %q: %w", to, err)
+		}
+	}
+
+	fromTime, err := parseModuleTime(matches[4])
+	if err != nil {
+		return Module{}, fmt.Errorf("parsing from time %q: %w", matches[4], err)
+	}
+
+	toTime, err := parseModuleTime(matches[5])
 	if err != nil {
-		return Module{}, fmt.Errorf("parsing to version %q: %w", to, err)
+		return Module{}, fmt.Errorf("parsing to time %q: %w", matches[5], err)
 	}
 
 	module := Module{
-		Name:         matches[1],
-		FromVersion:  from,
-		ToVersion:    to,
-		PatchUpgrade: to.Patch() > from.Patch(),
-		MinorUpgrade: to.Minor() > from.Minor(),
+		Name:        matches[1],
+		Indirect:    strings.Contains(moduleLine, indirectMarker),
+		FromVersion: from,
+		ToVersion:   to,
+		FromTime:    fromTime,
+		ToTime:      toTime,
+	}
+	if to != nil {
+		module.Update = classifyUpdate(from, to)
 	}
 
-	if module.MinorUpgrade {
-		module.PatchUpgrade = false
+	if matches[6] != "" {
+		module.Replace, err = parseReplacement(matches[6])
+		if err != nil {
+			return Module{}, err
+		}
 	}
 
 	return module, nil
 }
+
+func parseReplacement(value string) (*Replacement, error) {
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return &Replacement{Path: value}, nil
+	}
+
+	replacement := &Replacement{Path: value[:at]}
+	if version := value[at+1:]; version != "" {
+		parsed, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing replacement version %q: %w", version, err)
+		}
+		replacement.Version = parsed
+	}
+
+	return replacement, nil
+}
+
+func parseModuleTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
@@ -17,12 +19,25 @@ func Test_GetModules_ReturnsErrorFromListModules(t *testing.T) {
 		WithExecutor(&mockExecutor),
 	)
 
-	_, err := d.GetModules()
+	_, err := d.GetModules(context.Background())
 	require.Error(t, err)
 
 	assert.Contains(t, err.Error(), "listing modules: ")
 }
 
+func Test_GetModules_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{}),
+	)
+
+	_, err := d.GetModules(ctx)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
 func Test_GetModules_ReturnsErrorFromParseModules(t *testing.T) {
 	mockExecutor := MockExecutor{
 		CommandOutput: "invalid-output",
@@ -31,7 +46,7 @@ func Test_GetModules_ReturnsErrorFromParseModules(t *testing.T) {
 		WithExecutor(&mockExecutor),
 	)
 
-	_, err := d.GetModules()
+	_, err := d.GetModules(context.Background())
 	require.Error(t, err)
 
 	assert.Contains(t, err.Error(), "parsing modules: ")
@@ -43,19 +58,38 @@ func Test_ListModules_CallsExecutorRun(t *testing.T) {
 		WithExecutor(mockExecutor),
 	)
 
-	_, err := d.listModules()
+	_, err := d.listModules(context.Background())
 	require.NoError(t, err)
 
 	runCalls := mockExecutor.RunCalls
 	require.Len(t, runCalls, 1)
 
-	listArgs := "list -m -u -f '{{if (and (not (or .Main .Indirect)) .Update)}}==START=={{.Path}},{{.Version}},{{.Update.Version}}==END=={{end}}' all"
+	listArgs := "list -m -u -f '{{if not .Main}}==START=={{.Path}},{{.Version}},{{with .Update}}{{.Version}}{{end}}," +
+		"{{with .Time}}{{.Format \"2006-01-02T15:04:05Z07:00\"}}{{end}}," +
+		"{{with .Update}}{{with .Time}}{{.Format \"2006-01-02T15:04:05Z07:00\"}}{{end}}{{end}}," +
+		"{{with .Replace}}{{.Path}}@{{.Version}}{{end}}==END=={{if .Indirect}}==INDIRECT=={{end}}{{end}}' all"
 	assert.Equal(t, runCalls[0], RunCall{
 		Command: "go",
 		Args:    listArgs,
 	})
 }
 
+func Test_ListModules_RunsInDirWithEnv(t *testing.T) {
+	mockExecutor := &MockExecutor{}
+	d := NewDiscoverer(
+		WithExecutor(mockExecutor),
+		WithDir("services/api"),
+		WithEnv("GOFLAGS=-mod=mod"),
+	)
+
+	_, err := d.listModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, mockExecutor.RunCalls, 1)
+	assert.Equal(t, "services/api", mockExecutor.RunCalls[0].Dir)
+	assert.Equal(t, []string{"GOFLAGS=-mod=mod"}, mockExecutor.RunCalls[0].Env)
+}
+
 func Test_ListModules_ReturnsErrorFromExecutor(t *testing.T) {
 	wantError := fmt.Errorf("an-error-from-executor")
 	mockExecutor := MockExecutor{RunError: wantError}
@@ -63,7 +97,7 @@ func Test_ListModules_ReturnsErrorFromExecutor(t *testing.T) {
 		WithExecutor(&mockExecutor),
 	)
 
-	_, err := d.listModules()
+	_, err := d.listModules(context.Background())
 
 	assert.Error(t, wantError, err)
 }
@@ -85,7 +119,7 @@ func Test_ListModules_ReturnsModules(t *testing.T) {
 		WithExecutor(&mockExecutor),
 	)
 
-	moduleOutput, err := d.listModules()
+	moduleOutput, err := d.listModules(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, modulesListOutput, moduleOutput)
@@ -107,7 +141,7 @@ func Test_ListModules_HandlesLatestModules(t *testing.T) {
 		WithExecutor(&mockExecutor),
 	)
 
-	moduleOutput, err := d.listModules()
+	moduleOutput, err := d.listModules(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, result, moduleOutput)
@@ -176,24 +210,28 @@ func Test_ParseModules_ReturnsErrorWhenToVersionIsNotAValidSemver(t *testing.T)
 func Test_ParseModules_ReturnsExpectedModules(t *testing.T) {
 	wantModules := []Module{
 		{
-			Name:         "a-minor-upgrade",
-			FromVersion:  semver.MustParse("1.0.0"),
-			ToVersion:    semver.MustParse("1.1.0"),
-			MinorUpgrade: true,
+			Name:        "a-minor-upgrade",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.1.0"),
+			Update:      UpdateMinor,
+		},
+		{
+			Name:        "a-minor-upgrade-with-patch-upgrade",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.1.1"),
+			Update:      UpdateMinor,
 		},
 		{
-			Name:         "a-minor-upgrade-with-patch-upgrade",
-			FromVersion:  semver.MustParse("1.0.0"),
-			ToVersion:    semver.MustParse("1.1.1"),
-			PatchUpgrade: false,
-			MinorUpgrade: true,
+			Name:        "a-patch-upgrade",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.0.1"),
+			Update:      UpdatePatch,
 		},
 		{
-			Name:         "a-patch-upgrade",
-			FromVersion:  semver.MustParse("1.0.0"),
-			ToVersion:    semver.MustParse("1.0.1"),
-			PatchUpgrade: true,
-			MinorUpgrade: false,
+			Name:        "a-major-upgrade-with-lower-minor",
+			FromVersion: semver.MustParse("1.9.3"),
+			ToVersion:   semver.MustParse("2.0.0"),
+			Update:      UpdateMajor,
 		},
 	}
 	mockExecutor := MockExecutor{}
@@ -203,7 +241,7 @@ func Test_ParseModules_ReturnsExpectedModules(t *testing.T) {
 	moduleListOutput := modulesToListFormat(wantModules...)
 	modules, err := d.parseModules(moduleListOutput)
 	require.NoError(t, err)
-	require.Len(t, modules, 3)
+	require.Len(t, modules, 4)
 
 	assert.Equal(t, wantModules, modules)
 }
@@ -211,15 +249,16 @@ func Test_ParseModules_ReturnsExpectedModules(t *testing.T) {
 func Test_ParseModules_SkipsEmptyModuleLines(t *testing.T) {
 	wantModules := []Module{
 		{
-			Name:         "a-module-name",
-			FromVersion:  semver.MustParse("1.0.0"),
-			ToVersion:    semver.MustParse("1.1.0"),
-			MinorUpgrade: true,
+			Name:        "a-module-name",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.1.0"),
+			Update:      UpdateMinor,
 		},
 		{
 			Name:        "another-module-name",
 			FromVersion: semver.MustParse("1.0.0"),
 			ToVersion:   semver.MustParse("3.0.0"),
+			Update:      UpdateMajor,
 		},
 	}
 	var mockExecutor MockExecutor
@@ -240,207 +279,445 @@ func Test_ParseModules_SkipsEmptyModuleLines(t *testing.T) {
 	assert.Equal(t, wantModules, modules)
 }
 
-func Test_GetChangelog__CallsGivenHttpClient(t *testing.T) {
-	name := "github.com/stretchr/testify"
-	given := Module{
-		Name: name,
+func newValidModule() Module {
+	return Module{
+		Name: "github.com/project/repo",
 	}
+}
 
-	mockClient := NewMockHTTPClient()
-	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
-	)
+func modulesToListFormat(modules ...Module) string {
+	var result []string
+	for _, module := range modules {
+		result = append(result, moduleToListFormat(module))
+	}
+	return strings.Join(result, "\n")
+}
 
-	_, _ = d.GetChangelog(given)
+func moduleToListFormat(module Module) string {
+	return fmt.Sprintf("==START==%s,%s,%s,%s,%s,==END==", module.Name, module.FromVersion, module.ToVersion,
+		timeToListFormat(module.FromTime), timeToListFormat(module.ToTime))
+}
 
-	calls := mockClient.GetCalls()
-	assert.Len(t, calls, 1)
+func timeToListFormat(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
 }
 
-func Test_GetGithubRepoFromModule_ReturnsExpectedModule(t *testing.T) {
-	wantRepo := "a-project/a-wantRepo-name"
-	m := Module{
-		Name: fmt.Sprintf("github.com/%s", wantRepo),
+func Test_ParseModules_ParsesReleaseTimes(t *testing.T) {
+	fromTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	toTime := time.Date(2020, 6, 7, 8, 9, 10, 0, time.UTC)
+	wantModule := Module{
+		Name:        "example.com/a/module",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.0.1"),
+		FromTime:    fromTime,
+		ToTime:      toTime,
 	}
-	gotRepo, err := getGithubRepoFromModule(m)
+	d := NewDiscoverer()
+
+	modules, err := d.parseModules(moduleToListFormat(wantModule))
 	require.NoError(t, err)
 
-	assert.Equal(t, wantRepo, gotRepo)
+	require.Len(t, modules, 1)
+	assert.True(t, fromTime.Equal(modules[0].FromTime))
+	assert.True(t, toTime.Equal(modules[0].ToTime))
 }
 
-func Test_GetChangelog__CallsHttpClientWithExpectedQueryParams(t *testing.T) {
-	repo := "stretchr/testify"
-	name := fmt.Sprintf("github.com/%s", repo)
-	given := Module{
-		Name:         name,
-		PatchUpgrade: false,
-		MinorUpgrade: false,
-	}
+func Test_ParseModules_AllowsMissingReleaseTimes(t *testing.T) {
+	d := NewDiscoverer()
+
+	modules, err := d.parseModules("==START==example.com/a/module,v1.0.0,v1.0.1,,,==END==")
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.True(t, modules[0].FromTime.IsZero())
+	assert.True(t, modules[0].ToTime.IsZero())
+}
+
+func givenNow(t *testing.T, fixed time.Time) {
+	original := now
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = original })
+}
 
+func Test_GetModules_KeepsTargetsOlderThanCooldown(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	module := Module{
+		Name:        "example.com/a/module",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.0.1"),
+		ToTime:      time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
 	mockClient := NewMockHTTPClient()
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(&MockExecutor{CommandOutput: moduleToListFormat(module)}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithCooldown(7*24*time.Hour),
 	)
 
-	_, _ = d.GetChangelog(given)
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
 
-	calls := mockClient.GetCalls()
-	require.Len(t, calls, 1)
+	require.Len(t, modules, 1)
+	assert.Equal(t, module.ToVersion.String(), modules[0].ToVersion.String())
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_GetModules_DowngradesTargetsNewerThanCooldown(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	module := Module{
+		Name:        "example.com/a/module",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.2.0"),
+		ToTime:      time.Date(2020, 6, 9, 0, 0, 0, 0, time.UTC),
+	}
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.0.1\nv1.1.0\nv1.1.1\nv1.2.0\nv1.3.0-rc.1\n", nil),
+		newMockResponse(200, `{"Version":"v1.1.1","Time":"2020-06-08T00:00:00Z"}`, nil),
+		newMockResponse(200, `{"Version":"v1.1.0","Time":"2020-05-01T00:00:00Z"}`, nil),
+	)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: moduleToListFormat(module)}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithCooldown(7*24*time.Hour),
+	)
 
-	url := calls[0].URL
-	require.NotNil(t, url)
-	queryParams := url.RawQuery
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
 
-	wantSearch := fmt.Sprintf("repo:%s+filename:CHANGELOG.md", repo)
-	assert.Contains(t, queryParams, wantSearch)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.1.0", modules[0].ToVersion.Original())
+	assert.True(t, time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC).Equal(modules[0].ToTime))
+	assert.Equal(t, UpdateMinor, modules[0].Update)
 }
 
-func Test_GetChangelog_ReturnsErrorFromClient(t *testing.T) {
-	given := Module{
-		Name:         "github.com/stretchr/testify",
-		PatchUpgrade: false,
-		MinorUpgrade: false,
+func Test_GetModules_HidesModulesWithoutVersionsOlderThanCooldown(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	module := Module{
+		Name:        "example.com/a/module",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.0.1"),
+		ToTime:      time.Date(2020, 6, 9, 0, 0, 0, 0, time.UTC),
 	}
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\nv1.0.1\n", nil)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: moduleToListFormat(module)}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithCooldown(7*24*time.Hour),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
 
+	assert.Empty(t, modules)
+}
+
+func Test_GetModules_ReturnsErrorFromCooldownVersionLookup(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	module := Module{
+		Name:        "example.com/a/module",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.0.1"),
+		ToTime:      time.Date(2020, 6, 9, 0, 0, 0, 0, time.UTC),
+	}
 	mockClient := NewMockHTTPClient()
-	wantError := fmt.Errorf("an error from the HTTP Client")
-	mockClient.GivenErrorIsReturned(wantError)
+	mockClient.GivenResponseIsReturned(500, "", nil)
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(&MockExecutor{CommandOutput: moduleToListFormat(module)}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithCooldown(7*24*time.Hour),
 	)
 
-	_, err := d.GetChangelog(given)
+	_, err := d.GetModules(context.Background())
 	require.Error(t, err)
 
-	assert.EqualError(t, err, fmt.Sprintf("failed to make a request for changelog: %s", wantError))
+	assert.Contains(t, err.Error(), "applying cooldown: ")
 }
 
-func Test_GetChangelog_ReturnsMatchingErrorWhenCannotParseModuleName(t *testing.T) {
-	d := NewDiscoverer()
+func Test_GetReleaseNotes_ReturnsNotesFromFirstSuccessfulProvider(t *testing.T) {
+	failing := &MockReleaseNotesProvider{ReturnError: fmt.Errorf("no changelog")}
+	succeeding := &MockReleaseNotesProvider{ReturnNotes: "some notes"}
+	unused := &MockReleaseNotesProvider{ReturnNotes: "other notes"}
+	d := NewDiscoverer(
+		WithReleaseNotesProviders(failing, succeeding, unused),
+	)
 
-	_, err := d.GetChangelog(Module{Name: "not-a-valid-module-name"})
-	require.Error(t, err)
+	notes, err := d.GetReleaseNotes(context.Background(), newValidModule())
+	require.NoError(t, err)
 
-	assert.Contains(t, err.Error(), "unable to parse module name")
+	assert.Equal(t, "some notes", notes)
+	assert.Len(t, failing.Calls, 1)
+	assert.Len(t, succeeding.Calls, 1)
+	assert.Empty(t, unused.Calls)
 }
-func Test_GetChangelog_ReturnsUnmarshallingErrorWhenResponseInvalid(t *testing.T) {
-	mockClient := NewMockHTTPClient()
-	mockClient.GivenResponseIsReturned(200, "not-valid-json", nil)
+
+func Test_GetReleaseNotes_ReturnsErrorsFromAllProviders(t *testing.T) {
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithReleaseNotesProviders(
+			&MockReleaseNotesProvider{ReturnError: fmt.Errorf("first error")},
+			&MockReleaseNotesProvider{ReturnError: fmt.Errorf("second error")},
+		),
 	)
 
-	_, err := d.GetChangelog(Module{
-		Name: "github.com/foo/bar",
-	})
+	_, err := d.GetReleaseNotes(context.Background(), newValidModule())
 	require.Error(t, err)
 
-	assert.Contains(t, err.Error(), "unexpected response from github API:")
+	assert.Contains(t, err.Error(), "first error; second error")
 }
 
-func Test_GetChangelog_ReturnsExpectedURL(t *testing.T) {
-	module := newValidModule()
-	wantURL := "url"
-	githubResponse := GithubFileSearchResponse{
-		TotalCount: 1,
-		Items: []Item{
-			{Name: module.Name, Path: "CHANGELOG.md", HTMLURL: wantURL},
-		},
-	}
-	body, err := json.Marshal(githubResponse)
+func Test_ParseModules_SkipsModulesWithoutUpdates(t *testing.T) {
+	d := NewDiscoverer()
+
+	modules, err := d.parseModules("==START==example.com/a/module,v1.0.0,,,,==END==")
 	require.NoError(t, err)
 
+	assert.Empty(t, modules)
+}
+
+func Test_GetModules_OffersFirstTaggedReleaseAndLatestCommitForPseudoVersions(t *testing.T) {
+	pseudo := "v0.0.0-20200301000000-abcdefabcdef"
+	mockExecutor := &MockExecutor{
+		CommandOutput: "==START==example.com/a/module," + pseudo + ",,,,==END==",
+		OutputsForArgs: map[string]string{
+			"list -m -json example.com/a/module@HEAD": `{"Version":"v0.3.1-0.20200601000000-0123456789ab","Time":"2020-06-01T00:00:00Z"}`,
+		},
+	}
 	mockClient := NewMockHTTPClient()
-	mockClient.GivenResponseIsReturned(200, string(body), nil)
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v0.1.0\nv0.2.0\nv0.3.0\n", nil),
+		newMockResponse(200, `{"Version":"v0.1.0","Time":"2020-01-01T00:00:00Z"}`, nil),
+		newMockResponse(200, `{"Version":"v0.2.0","Time":"2020-04-01T00:00:00Z"}`, nil),
+	)
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(mockExecutor),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
 	)
 
-	gotChangelog, err := d.GetChangelog(module)
+	modules, err := d.GetModules(context.Background())
 	require.NoError(t, err)
 
-	assert.Equal(t, wantURL, gotChangelog)
+	require.Len(t, modules, 2)
+	assert.Equal(t, "v0.2.0", modules[0].ToVersion.Original())
+	assert.Equal(t, firstTaggedReleaseLabel, modules[0].Label)
+	assert.Equal(t, UpdateMinor, modules[0].Update)
+	assert.Equal(t, "v0.3.1-0.20200601000000-0123456789ab", modules[1].ToVersion.Original())
+	assert.Equal(t, latestCommitLabel, modules[1].Label)
+	assert.Equal(t, UpdatePseudoVersion, modules[1].Update)
 }
 
-func newValidModule() Module {
-	return Module{
-		Name: "github.com/project/repo",
+func Test_GetModules_KeepsGoListUpdateForPseudoVersions(t *testing.T) {
+	pseudo := "v0.0.0-20200301000000-abcdefabcdef"
+	mockExecutor := &MockExecutor{
+		CommandOutput: "==START==example.com/a/module," + pseudo + ",v0.2.0,,,==END==",
+		OutputsForArgs: map[string]string{
+			"list -m -json example.com/a/module@HEAD": `{"Version":"v0.2.0","Time":"2020-04-01T00:00:00Z"}`,
+		},
 	}
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v0.2.0\n", nil),
+		newMockResponse(200, `{"Version":"v0.2.0","Time":"2020-04-01T00:00:00Z"}`, nil),
+	)
+	d := NewDiscoverer(
+		WithExecutor(mockExecutor),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v0.2.0", modules[0].ToVersion.Original())
+	assert.Empty(t, modules[0].Label)
 }
 
-func Test_GetChangelog_ReturnsRootChangelogIfMultipleFound(t *testing.T) {
-	githubResponse := GithubFileSearchResponse{
-		TotalCount: 2,
-		Items: []Item{
-			{Name: "another module", Path: "another-path", HTMLURL: "two-url"},
-			{Name: "name", Path: "CHANGELOG.md", HTMLURL: "one-url"},
+func Test_LatestCommit_FallsBackToBranchNames(t *testing.T) {
+	mockExecutor := &MockExecutor{
+		OutputsForArgs: map[string]string{
+			"list -m -json example.com/a/module@master": `{"Version":"v0.0.0-20200601000000-0123456789ab","Time":"2020-06-01T00:00:00Z"}`,
 		},
 	}
-	body, err := json.Marshal(githubResponse)
+	d := NewDiscoverer(
+		WithExecutor(mockExecutor),
+	)
+	module := Module{Name: "example.com/a/module", FromVersion: semver.MustParse("v0.0.0-20200301000000-abcdefabcdef")}
+
+	latest, ok := d.latestCommit(context.Background(), module)
+	require.True(t, ok)
+
+	assert.Equal(t, "v0.0.0-20200601000000-0123456789ab", latest.ToVersion.Original())
+	require.Len(t, mockExecutor.RunCalls, 3)
+	assert.Equal(t, "list -m -json example.com/a/module@main", mockExecutor.RunCalls[1].Args)
+}
+
+func Test_GetModules_OffersNewestPrereleaseWhenOptedIn(t *testing.T) {
+	enabled := true
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.1.0\nv1.2.0-beta.1\nv1.2.0-rc.1\nv1.2.0-rc.10\nv1.2.0-rc.2\n", nil),
+		newMockResponse(200, `{"Version":"v1.2.0-rc.10","Time":"2020-06-01T00:00:00Z"}`, nil),
+	)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,v1.1.0,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithConfig(Config{Modules: map[string]ModuleConfig{"example.com/a/module": {Prerelease: &enabled}}}),
+	)
+
+	modules, err := d.GetModules(context.Background())
 	require.NoError(t, err)
 
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.2.0-rc.10", modules[0].ToVersion.Original())
+	assert.Equal(t, UpdatePrerelease, modules[0].Update)
+	assert.True(t, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC).Equal(modules[0].ToTime))
+}
+
+func Test_GetModules_OffersPrereleaseWithoutStableUpdateWhenOptedIn(t *testing.T) {
 	mockClient := NewMockHTTPClient()
-	mockClient.GivenResponseIsReturned(200, string(body), nil)
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.1.0-rc.1\n", nil),
+		newMockResponse(200, `{"Version":"v1.1.0-rc.1","Time":"2020-06-01T00:00:00Z"}`, nil),
+	)
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithConfig(Config{Prerelease: true}),
 	)
 
-	changelog, err := d.GetChangelog(newValidModule())
+	modules, err := d.GetModules(context.Background())
 	require.NoError(t, err)
 
-	assert.Equal(t, githubResponse.Items[1].HTMLURL, changelog)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.1.0-rc.1", modules[0].ToVersion.Original())
 }
 
-func Test_GetChangelog_ReturnsErrorWhenChangelogIsNotFound(t *testing.T) {
-	githubResponse := GithubFileSearchResponse{
-		TotalCount: 1,
-		Items: []Item{
-			{Name: "name", Path: "a-path", HTMLURL: "one-url"},
-		},
-	}
-	body, err := json.Marshal(githubResponse)
+func Test_GetModules_KeepsStableTargetWhenItIsNewestWhenOptedIn(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\nv1.1.0-rc.1\nv1.1.0\n", nil)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,v1.1.0,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithConfig(Config{Prerelease: true}),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.1.0", modules[0].ToVersion.Original())
+	assert.Len(t, mockClient.GetCalls(), 1)
+}
+
+func Test_GetModules_IgnoresPrereleasesByDefault(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,v1.1.0,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+	)
+
+	modules, err := d.GetModules(context.Background())
 	require.NoError(t, err)
 
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.1.0", modules[0].ToVersion.Original())
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_GetModules_OffersUpdatesOfVersionedReplacements(t *testing.T) {
 	mockClient := NewMockHTTPClient()
-	mockClient.GivenResponseIsReturned(200, string(body), nil)
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.1.0\nv1.2.0-rc.1\n", nil),
+		newMockResponse(200, `{"Version":"v1.1.0","Time":"2020-06-01T00:00:00Z"}`, nil),
+	)
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(&MockExecutor{
+			CommandOutput: "==START==example.com/original,v1.0.0,v2.0.0,,,example.com/fork@v1.0.0==END==",
+		}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
 	)
 
-	_, err = d.GetChangelog(newValidModule())
-	require.Error(t, err)
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
 
-	assert.Contains(t, err.Error(), "failed to find a root level CHANGELOG.md")
+	require.Len(t, modules, 1)
+	assert.Equal(t, "example.com/original", modules[0].Name)
+	assert.Equal(t, "example.com/fork", modules[0].SourcePath())
+	assert.Equal(t, "example.com/original => example.com/fork", modules[0].DisplayName())
+	assert.Equal(t, "v1.0.0", modules[0].FromVersion.Original())
+	assert.Equal(t, "v1.1.0", modules[0].ToVersion.Original())
+	assert.Equal(t, UpdateMinor, modules[0].Update)
+	assert.Equal(t, "https://proxy.example/example.com/fork/@v/list", mockClient.GetCalls()[0].URL.String())
 }
 
-func Test_GetChangelog_ReturnsErrorWhenNoSearchResultsFound(t *testing.T) {
-	githubResponse := GithubFileSearchResponse{
-		TotalCount: 0,
-		Items:      []Item{},
-	}
-	body, err := json.Marshal(githubResponse)
+func Test_GetModules_SkipsUpToDateReplacements(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\n", nil)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{
+			CommandOutput: "==START==example.com/original,v1.0.0,v2.0.0,,,example.com/fork@v1.0.0==END==",
+		}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+	)
+
+	modules, err := d.GetModules(context.Background())
 	require.NoError(t, err)
 
+	assert.Empty(t, modules)
+}
+
+func Test_GetModules_SkipsPrivateReplacementsWithoutAskingProxy(t *testing.T) {
 	mockClient := NewMockHTTPClient()
-	mockClient.GivenResponseIsReturned(200, string(body), nil)
 	d := NewDiscoverer(
-		WithHTTPClient(mockClient),
+		WithExecutor(&MockExecutor{
+			CommandOutput: "==START==example.com/original,v1.0.0,v2.0.0,,,corp.example.com/fork@v1.0.0==END==",
+		}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "", WithNoProxy("corp.example.com"))),
 	)
 
-	_, err = d.GetChangelog(newValidModule())
-	require.Error(t, err)
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
 
-	assert.Contains(t, err.Error(), "failed to find a root level CHANGELOG.md")
+	assert.Empty(t, modules)
+	assert.Equal(t, []string{"skipping example.com/original, whose replacement corp.example.com/fork matches GONOPROXY"},
+		d.Warnings)
+	assert.Empty(t, mockClient.GetCalls())
 }
 
-func modulesToListFormat(modules ...Module) string {
-	var result []string
-	for _, module := range modules {
-		result = append(result, moduleToListFormat(module))
-	}
-	return strings.Join(result, "\n")
+func Test_GetModules_WarnsAboutFilesystemReplacements(t *testing.T) {
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{
+			CommandOutput: "==START==example.com/original,v1.0.0,v2.0.0,,,../fork@==END==",
+		}),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, modules)
+	assert.Equal(t, []string{"skipping example.com/original, which is replaced by the local directory ../fork"}, d.Warnings)
 }
 
-func moduleToListFormat(module Module) string {
-	return fmt.Sprintf("==START==%s,%s,%s==END==", module.Name, module.FromVersion, module.ToVersion)
+func Test_GetModules_SkipsExcludedPrereleases(t *testing.T) {
+	config := Config{Prerelease: true}
+	config.AddExclude("example.com/a/module", "v1.2.0-rc.2", "broken")
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.2.0-rc.1\nv1.2.0-rc.2\n", nil),
+		newMockResponse(200, `{"Version":"v1.2.0-rc.1","Time":"2020-06-01T00:00:00Z"}`, nil),
+	)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithConfig(config),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "v1.2.0-rc.1", modules[0].ToVersion.Original())
 }
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeProxyDir = "testdata/proxy"
+
+// newFakeProxy serves the modules in testdata/proxy, laid out as <module path>@<version> directories, with the
+// module proxy protocol.
+func newFakeProxy(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		at := strings.Index(r.URL.Path, "/@v/")
+		if at < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		modulePath, file := strings.TrimPrefix(r.URL.Path[:at], "/"), r.URL.Path[at+len("/@v/"):]
+
+		if file == "list" {
+			versions := fakeProxyVersions(t, modulePath)
+			if len(versions) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, strings.Join(versions, "\n"))
+			return
+		}
+
+		ext := filepath.Ext(file)
+		version := strings.TrimSuffix(file, ext)
+		dir := filepath.Join(fakeProxyDir, filepath.FromSlash(modulePath)+"@"+version)
+		if _, err := os.Stat(dir); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch ext {
+		case ".info":
+			fmt.Fprintf(w, `{"Version":%q,"Time":"2020-01-01T00:00:00Z"}`, version)
+		case ".mod":
+			http.ServeFile(w, r, filepath.Join(dir, "go.mod"))
+		case ".zip":
+			w.Write(fakeModuleZip(t, dir, modulePath+"@"+version))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func fakeProxyVersions(t *testing.T, modulePath string) []string {
+	dirs, err := filepath.Glob(filepath.Join(fakeProxyDir, filepath.FromSlash(modulePath)+"@*"))
+	require.NoError(t, err)
+
+	var versions []string
+	for _, dir := range dirs {
+		versions = append(versions, dir[strings.LastIndex(dir, "@")+1:])
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func fakeModuleZip(t *testing.T, dir string, prefix string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, err)
+
+		entry, err := writer.Create(prefix + "/" + file.Name())
+		require.NoError(t, err)
+		_, err = entry.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+// givenMainModule writes a throwaway main module and returns its directory.
+func givenMainModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "gomo-e2e")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+// offlineTransport fails every request except those to the fake proxy, so that a missing fixture shows up as missing
+// release notes rather than as a request to a real code host.
+type offlineTransport struct {
+	proxyHost string
+}
+
+func (o offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != o.proxyHost {
+		return nil, fmt.Errorf("the end-to-end tests can't reach %s", req.URL.Host)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// hermeticOptions runs gomo in dir against the fake proxy only, with a module cache and a gomo cache that are removed
+// afterwards.
+func hermeticOptions(t *testing.T, dir string, proxyURL string) cliOptions {
+	proxy, err := url.Parse(proxyURL)
+	require.NoError(t, err)
+	cacheDir, err := ioutil.TempDir("", "gomo-cache")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+	modCache, err := ioutil.TempDir("", "gomo-modcache")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(modCache) })
+
+	return cliOptions{
+		dir: dir,
+		env: envFlag{
+			"GOPROXY=" + proxyURL,
+			"GOMODCACHE=" + modCache,
+			"GOFLAGS=-mod=mod -modcacherw",
+			"GOSUMDB=off",
+			"GONOSUMDB=",
+			"GOPRIVATE=",
+			"GONOPROXY=",
+			"GOTOOLCHAIN=local",
+		},
+		cacheDir:   cacheDir,
+		userConfig: filepath.Join(cacheDir, userConfigFilename),
+		transport:  offlineTransport{proxyHost: proxy.Host},
+		tools:      toolsInclude,
+		timeout:    time.Minute,
+		jobs:       defaultWorkers,
+	}
+}
+
+// requireGoCommand skips tests that run the go command in short mode. They need a recent go command: older ones ignore
+// GOMODCACHE, which keeps them off the real module cache, and reject go 1.21 main modules. Those using features of
+// newer Go releases, such as tool directives, are skipped when the toolchain is older than minGo too.
+func requireGoCommand(t *testing.T, minGo string) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+
+	current, err := parseGoVersion(strings.TrimPrefix(runtime.Version(), "go"))
+	if err != nil {
+		return
+	}
+	if current.LessThan(semver.MustParse(minGo)) {
+		t.Skipf("needs Go %s or later", minGo)
+	}
+}
+
+// offerRecorder remembers which modules a prompter was offered.
+type offerRecorder struct {
+	Prompter Prompter
+	Offered  []Module
+}
+
+func (r *offerRecorder) AskForUpgrades(modules []Module) (Choices, error) {
+	r.Offered = append(r.Offered, modules...)
+	return r.Prompter.AskForUpgrades(modules)
+}
+
+func Test_EndToEnd_UpgradesSelectedModules(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	dir := givenMainModule(t, map[string]string{
+		"go.mod": "module example.com/main\n\ngo 1.21\n\nrequire (\n\texample.com/lib v1.0.0\n\texample.com/other v0.1.0\n)\n",
+		"main.go": "package main\n\nimport (\n\t\"example.com/lib\"\n\t\"example.com/other\"\n)\n\n" +
+			"func main() {\n\tprintln(lib.Hello(), other.Version)\n}\n",
+	})
+	answers := &offerRecorder{Prompter: NewScriptPrompter([]Answer{{Path: "example.com/lib"}})}
+
+	err := run(context.Background(), hermeticOptions(t, dir, proxy.URL), answers)
+	require.NoError(t, err)
+
+	require.Len(t, answers.Offered, 2)
+	assert.Equal(t, "example.com/lib", answers.Offered[0].Name)
+	assert.Equal(t, semver.MustParse("v1.1.0"), answers.Offered[0].ToVersion)
+	assert.Contains(t, answers.Offered[0].Changelog, "Add Goodbye.")
+	assert.Empty(t, answers.Offered[0].APIChanges)
+	assert.Equal(t, "example.com/other", answers.Offered[1].Name)
+	assert.Equal(t, semver.MustParse("v0.2.0"), answers.Offered[1].ToVersion)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "example.com/lib v1.1.0\n")
+	assert.Contains(t, string(content), "example.com/other v0.1.0\n")
+}
+
+func Test_EndToEnd_ExcludesVersionsAndOffersTheNextOnes(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	dir := givenMainModule(t, map[string]string{
+		"go.mod":  "module example.com/main\n\ngo 1.21\n\nrequire example.com/lib v1.0.0\n",
+		"main.go": "package main\n\nimport \"example.com/lib\"\n\nfunc main() {\n\tprintln(lib.Hello())\n}\n",
+	})
+	answers, err := ReadAnswers(strings.NewReader("exclude example.com/lib@v1.1.0 breaks Hello\nexample.com/lib\n"))
+	require.NoError(t, err)
+
+	err = run(context.Background(), hermeticOptions(t, dir, proxy.URL), NewScriptPrompter(answers))
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "example.com/lib v1.0.1\n")
+	assert.Contains(t, string(content), "exclude example.com/lib v1.1.0\n")
+
+	config, err := loadConfig(filepath.Join(dir, configFilename))
+	require.NoError(t, err)
+	assert.Equal(t, []ExcludeConfig{{Version: "v1.1.0", Reason: "breaks Hello"}}, config.Modules["example.com/lib"].Excludes)
+}
+
+func Test_EndToEnd_QueriesProxyForUpdates(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	dir := givenMainModule(t, map[string]string{
+		"go.mod": "module example.com/main\n\ngo 1.21\n\nrequire (\n\texample.com/lib v1.0.0\n\texample.com/other v0.2.0\n)\n",
+		"main.go": "package main\n\nimport (\n\t\"example.com/lib\"\n\t\"example.com/other\"\n)\n\n" +
+			"func main() {\n\tprintln(lib.Hello(), other.Version)\n}\n",
+	})
+	answers := &offerRecorder{Prompter: NewScriptPrompter([]Answer{{Path: "example.com/lib"}})}
+
+	opts := hermeticOptions(t, dir, proxy.URL)
+	opts.queryProxy = true
+
+	err := run(context.Background(), opts, answers)
+	require.NoError(t, err)
+
+	require.Len(t, answers.Offered, 1)
+	assert.Equal(t, "example.com/lib", answers.Offered[0].Name)
+	assert.Equal(t, semver.MustParse("v1.1.0"), answers.Offered[0].ToVersion)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "example.com/lib v1.1.0\n")
+}
+
+func Test_EndToEnd_UpgradesReplacementsAndKeepsTheBuildWorking(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	dir := givenMainModule(t, map[string]string{
+		"go.mod": "module example.com/main\n\ngo 1.21\n\nrequire example.com/other v0.1.0\n\n" +
+			"replace example.com/other => example.com/fork v0.1.0\n",
+		"main.go": "package main\n\nimport \"example.com/other\"\n\nfunc main() {\n\tprintln(other.Version)\n}\n",
+	})
+	opts := hermeticOptions(t, dir, proxy.URL)
+
+	err := run(context.Background(), opts, NewScriptPrompter([]Answer{{Path: "example.com/other"}}))
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "replace example.com/other => example.com/fork v0.2.0\n")
+
+	build := exec.Command("go", "build", "./...")
+	build.Dir = dir
+	// -mod=readonly makes the build fail on missing go.sum entries instead of adding them.
+	build.Env = append(append(os.Environ(), opts.env...), "GOFLAGS=-mod=readonly -modcacherw")
+	output, err := build.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
+
+func Test_EndToEnd_OffersToolDirectiveDependencies(t *testing.T) {
+	requireGoCommand(t, "1.24")
+	proxy := newFakeProxy(t)
+	// This is how go get -tool records a tool: its module is an indirect requirement.
+	dir := givenMainModule(t, map[string]string{
+		"go.mod": "module example.com/main\n\ngo 1.24\n\ntool example.com/tool\n\nrequire (\n" +
+			"\texample.com/lib v1.0.0\n\texample.com/other v0.1.0 // indirect\n\texample.com/tool v1.0.0 // indirect\n)\n",
+		"main.go": "package main\n\nimport \"example.com/lib\"\n\nfunc main() {\n\tprintln(lib.Hello())\n}\n",
+	})
+	answers := &offerRecorder{Prompter: &PolicyPrompter{Kinds: map[UpdateKind]bool{UpdateMinor: true}}}
+
+	opts := hermeticOptions(t, dir, proxy.URL)
+	opts.tools = toolsOnly
+
+	err := run(context.Background(), opts, answers)
+	require.NoError(t, err)
+
+	require.Len(t, answers.Offered, 1)
+	assert.Equal(t, "example.com/tool", answers.Offered[0].Name)
+	assert.True(t, answers.Offered[0].Tool)
+	assert.Equal(t, semver.MustParse("v1.1.0"), answers.Offered[0].ToVersion)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "example.com/tool v1.1.0 // indirect\n")
+}
+
+func Test_ReadBinaries_ReadsBuildInfoOfInstalledBinaries(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	opts := hermeticOptions(t, "", proxy.URL)
+	gobin, err := ioutil.TempDir("", "gomo-gobin")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(gobin) })
+	executor := NewCommandExecutor()
+	install := Command{Name: "go", Args: []string{"install", "example.com/tool@v1.0.0"}, Dir: gobin,
+		Env: append([]string(opts.env), "GOBIN="+gobin)}
+	_, err = executor.Run(context.Background(), install)
+	require.NoError(t, err)
+
+	binaries, err := readBinaries(context.Background(), executor, gobin)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Binary{
+		{Name: "tool", Package: "example.com/tool", Module: "example.com/tool", ModuleVersion: "v1.0.0"},
+	}, binaries)
+}
+
+func Test_GetModules_WarnsAboutModuleQueriesThatFail(t *testing.T) {
+	requireGoCommand(t, "1.21")
+	proxy := newFakeProxy(t)
+	opts := hermeticOptions(t, "", proxy.URL)
+	dir, err := ioutil.TempDir("", "gomo-bin")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	d := NewDiscoverer(
+		WithExecutor(NewCommandExecutor()),
+		WithDir(dir),
+		WithEnv(opts.env...),
+		WithModuleQueries("example.com/missing@v1.0.0", "example.com/tool@v1.0.0"),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "example.com/tool", modules[0].Name)
+	assert.Equal(t, semver.MustParse("v1.1.0"), modules[0].ToVersion)
+	require.Len(t, d.Warnings, 1)
+	assert.Contains(t, d.Warnings[0], "skipping example.com/missing, which couldn't be looked up: ")
+}
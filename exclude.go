@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const defaultExcludeReason = "marked as bad in gomo"
+
+// Exclusion is a version that should never be selected, along with why.
+type Exclusion struct {
+	Module Module
+	Reason string
+}
+
+func runExclude(args []string) error {
+	flags := flag.NewFlagSet("gomo exclude", flag.ContinueOnError)
+	reason := flags.String("reason", defaultExcludeReason, "why the version must not be used")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: gomo exclude [--reason text] <module>@<version>")
+	}
+
+	path, version, err := parseModuleVersion(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := excludeVersion("go.mod", configFilename, path, version, *reason); err != nil {
+		return err
+	}
+
+	fmt.Printf("Excluded %s@%s\n", path, version)
+	return nil
+}
+
+func parseModuleVersion(arg string) (string, string, error) {
+	at := strings.LastIndex(arg, "@")
+	if at <= 0 || at == len(arg)-1 {
+		return "", "", fmt.Errorf("expected <module>@<version>, got %q", arg)
+	}
+
+	path, version := arg[:at], arg[at+1:]
+	if _, err := semver.NewVersion(version); err != nil || !strings.HasPrefix(version, "v") {
+		return "", "", fmt.Errorf("invalid version %q", version)
+	}
+
+	return path, version, nil
+}
+
+// excludeVersion writes an exclude directive so that the go command never selects the version, and records the
+// reason in gomo's config.
+func excludeVersion(modFilePath string, configPath string, path string, version string, reason string) error {
+	f, err := ReadModFile(modFilePath)
+	if err != nil {
+		return err
+	}
+	f.AddExclude(path, version)
+	if err := f.Write(); err != nil {
+		return err
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	config.AddExclude(path, version, reason)
+
+	return saveConfig(configPath, config)
+}
+
+func applyExclusions(modFilePath string, configPath string, exclusions []Exclusion) error {
+	for _, exclusion := range exclusions {
+		module := exclusion.Module
+		if err := excludeVersion(modFilePath, configPath, module.SourcePath(), module.ToVersion.Original(), exclusion.Reason); err != nil {
+			return fmt.Errorf("excluding %s@%s: %w", module.SourcePath(), module.ToVersion.Original(), err)
+		}
+	}
+
+	return nil
+}
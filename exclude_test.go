@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseModuleVersion_SplitsModuleAndVersion(t *testing.T) {
+	path, version, err := parseModuleVersion("github.com/foo/bar/v2@v2.1.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "github.com/foo/bar/v2", path)
+	assert.Equal(t, "v2.1.0", version)
+}
+
+func Test_ParseModuleVersion_ReturnsErrorForInvalidArguments(t *testing.T) {
+	for _, arg := range []string{"github.com/foo/bar", "@v1.0.0", "github.com/foo/bar@", "github.com/foo/bar@latest", "github.com/foo/bar@1.0.0"} {
+		_, _, err := parseModuleVersion(arg)
+
+		assert.Error(t, err, arg)
+	}
+}
+
+func Test_ExcludeVersion_WritesExcludeDirectiveAndReason(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-exclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	modFile := filepath.Join(dir, "go.mod")
+	configFile := filepath.Join(dir, configFilename)
+	require.NoError(t, ioutil.WriteFile(modFile, []byte("module example.com/main\n\nrequire example.com/a v1.0.0\n"), 0644))
+
+	err = excludeVersion(modFile, configFile, "example.com/a", "v1.1.0", "panics on startup")
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(modFile)
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/main\n\nrequire example.com/a v1.0.0\n\nexclude example.com/a v1.1.0\n", string(content))
+
+	config, err := loadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []ExcludeConfig{{Version: "v1.1.0", Reason: "panics on startup"}}, config.Modules["example.com/a"].Excludes)
+}
+
+func Test_ApplyExclusions_ExcludesTargetVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-exclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	modFile := filepath.Join(dir, "go.mod")
+	configFile := filepath.Join(dir, configFilename)
+	require.NoError(t, ioutil.WriteFile(modFile, []byte("module example.com/main\n"), 0644))
+
+	err = applyExclusions(modFile, configFile, []Exclusion{
+		{Module: Module{Name: "example.com/a", ToVersion: semver.MustParse("v1.1.0")}, Reason: "broken"},
+	})
+	require.NoError(t, err)
+
+	config, err := loadConfig(configFile)
+	require.NoError(t, err)
+	assert.True(t, config.IsExcluded("example.com/a", "v1.1.0"))
+}
+
+func Test_ExcludeVersion_ReturnsErrorWithoutModFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-exclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = excludeVersion(filepath.Join(dir, "go.mod"), filepath.Join(dir, configFilename), "example.com/a", "v1.1.0", "")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "reading ")
+}
@@ -1,27 +1,165 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
 )
 
+// commandWaitDelay is how long an interrupted command gets to exit cleanly before it is killed.
+const commandWaitDelay = 5 * time.Second
+
 type Executor interface {
-	Run(command string, commandArgs ...string) (string, error)
+	Run(ctx context.Context, command Command) (Result, error)
+}
+
+// Command is a command to run. Dir defaults to the current directory, and Env holds KEY=value overrides of the
+// inherited environment.
+type Command struct {
+	Name string
+	Args []string
+	Dir  string
+	Env  []string
+}
+
+func (c Command) String() string {
+	line := strings.Join(append([]string{c.Name}, c.Args...), " ")
+	if c.Dir != "" {
+		line += fmt.Sprintf(" (in %s)", c.Dir)
+	}
+	return line
+}
+
+// Result is what a command printed and how it exited. It is returned alongside errors too, so that callers can
+// inspect the output of failed commands.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+type CommandExecutor struct {
+	Timeout time.Duration
+	Stream  io.Writer
+}
+
+type CommandExecutorOption func(*CommandExecutor)
+
+func NewCommandExecutor(options ...CommandExecutorOption) *CommandExecutor {
+	c := &CommandExecutor{}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// WithCommandTimeout limits how long each command may run for. Zero means no limit.
+func WithCommandTimeout(timeout time.Duration) CommandExecutorOption {
+	return func(c *CommandExecutor) {
+		c.Timeout = timeout
+	}
+}
+
+// WithStreamOutput echoes each command and copies its output to w as it runs, so that slow commands show progress.
+func WithStreamOutput(w io.Writer) CommandExecutorOption {
+	return func(c *CommandExecutor) {
+		c.Stream = &lockedWriter{w: w}
+	}
+}
+
+// lockedWriter serialises the writes of the commands' echoes, stdout and stderr, which are copied concurrently.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
 }
 
-type CommandExecutor struct{}
+// Run runs a command until it exits or ctx is done, in which case the command is interrupted so that the go command
+// can clean up after itself, and killed if it hasn't exited after commandWaitDelay.
+func (c *CommandExecutor) Run(ctx context.Context, command Command) (Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	commandLine := command.String()
+	cmd := exec.Command(command.Name, command.Args...)
+	cmd.Dir = command.Dir
+	if len(command.Env) > 0 {
+		cmd.Env = append(os.Environ(), command.Env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if c.Stream != nil {
+		fmt.Fprintf(c.Stream, "$ %s\n", commandLine)
+		cmd.Stdout, cmd.Stderr = io.MultiWriter(&stdout, c.Stream), io.MultiWriter(&stderr, c.Stream)
+	}
+
+	start := time.Now()
+	err := wait(ctx, cmd)
+	result := Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(start),
+	}
+
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		return result, nil
+	}
 
-func NewCommandExecutor() *CommandExecutor {
-	return &CommandExecutor{}
+	var exitErr *exec.ExitError
+	if message := strings.TrimSpace(result.Stderr); message != "" && errors.As(err, &exitErr) {
+		return result, fmt.Errorf("executing command %q: %w: %s", commandLine, err, message)
+	}
+	return result, fmt.Errorf("executing command %q: %w", commandLine, err)
 }
 
-func (c *CommandExecutor) Run(command string, commandArgs ...string) (string, error) {
-	cmd := exec.Command(command, commandArgs...)
+// wait runs cmd until it exits, interrupting it when ctx is done and killing it if it is still running
+// commandWaitDelay later.
+func wait(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
 
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("executing command %q: %w", fmt.Sprintf("%s %s", command, commandArgs), err)
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
 	}
 
-	return string(output), nil
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(commandWaitDelay):
+		_ = cmd.Process.Kill()
+		return <-done
+	}
 }
@@ -1,23 +1,38 @@
 package main
 
-import "strings"
+import (
+	"context"
+	"strings"
+)
 
 type MockExecutor struct {
-	RunError      error
-	RunCalls      []RunCall
-	CommandOutput string
+	RunError       error
+	RunCalls       []RunCall
+	CommandOutput  string
+	OutputsForArgs map[string]string
 }
 
 type RunCall struct {
 	Command string
 	Args    string
+	Dir     string
+	Env     []string
 }
 
-func (e *MockExecutor) Run(command string, commandArgs ...string) (string, error) {
+func (e *MockExecutor) Run(ctx context.Context, command Command) (Result, error) {
+	args := strings.Join(command.Args, " ")
 	e.RunCalls = append(e.RunCalls, RunCall{
-		Command: command,
-		Args:    strings.Join(commandArgs, " "),
+		Command: command.Name,
+		Args:    args,
+		Dir:     command.Dir,
+		Env:     command.Env,
 	})
 
-	return e.CommandOutput, e.RunError
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	if output, ok := e.OutputsForArgs[args]; ok {
+		return Result{Stdout: output}, e.RunError
+	}
+	return Result{Stdout: e.CommandOutput}, e.RunError
 }
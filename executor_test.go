@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CommandExecutor_ReturnsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on echo")
+	}
+
+	result, err := NewCommandExecutor().Run(context.Background(), Command{Name: "echo", Args: []string{"hello"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello\n", result.Stdout)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.True(t, result.Duration > 0)
+}
+
+func Test_CommandExecutor_IncludesStderrInErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	result, err := NewCommandExecutor().Run(context.Background(), Command{
+		Name: "sh",
+		Args: []string{"-c", "echo 'go: no such module' >&2; exit 3"},
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "go: no such module\n", result.Stderr)
+	assert.Contains(t, err.Error(), "exit status 3: go: no such module")
+}
+
+func Test_CommandExecutor_StreamsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	var stream bytes.Buffer
+	command := Command{Name: "sh", Args: []string{"-c", "echo out; echo err >&2"}}
+	result, err := NewCommandExecutor(WithStreamOutput(&stream)).Run(context.Background(), command)
+	require.NoError(t, err)
+
+	assert.Equal(t, "out\n", result.Stdout)
+	assert.Equal(t, "err\n", result.Stderr)
+	assert.Contains(t, stream.String(), "$ sh -c echo out; echo err >&2\n")
+	assert.Contains(t, stream.String(), "out\n")
+	assert.Contains(t, stream.String(), "err\n")
+}
+
+func Test_CommandExecutor_SerialisesStreamsOfConcurrentCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	var stream bytes.Buffer
+	executor := NewCommandExecutor(WithStreamOutput(&stream))
+	command := Command{Name: "sh", Args: []string{"-c", "echo out; echo err >&2"}}
+	err := parallel(context.Background(), 4, 4, func(int) {
+		_, err := executor.Run(context.Background(), command)
+		assert.NoError(t, err)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, strings.Count(stream.String(), "$ sh -c"))
+}
+
+func Test_CommandExecutor_InterruptsCommandsThatTimeOut(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sleep")
+	}
+
+	start := time.Now()
+	_, err := NewCommandExecutor(WithCommandTimeout(50*time.Millisecond)).Run(context.Background(), Command{Name: "sleep", Args: []string{"10"}})
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(commandWaitDelay))
+}
+
+func Test_CommandExecutor_StopsWhenContextIsCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sleep")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := NewCommandExecutor().Run(ctx, Command{Name: "sleep", Args: []string{"10"}})
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func Test_CommandExecutor_RunsInDirWithEnvOverrides(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	dir, err := ioutil.TempDir("", "gomo-executor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	result, err := NewCommandExecutor().Run(context.Background(), Command{
+		Name: "sh",
+		Args: []string{"-c", "pwd; echo $GOFLAGS"},
+		Dir:  dir,
+		Env:  []string{"GOFLAGS=-mod=mod"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, dir+"\n-mod=mod\n", result.Stdout)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type Forge string
+
+const (
+	ForgeGithub    Forge = "github"
+	ForgeGitlab    Forge = "gitlab"
+	ForgeBitbucket Forge = "bitbucket"
+	ForgeGitea     Forge = "gitea"
+)
+
+type Repository struct {
+	Forge  Forge
+	Host   string
+	Path   string
+	Subdir string
+}
+
+func (r Repository) URL() string {
+	return fmt.Sprintf("https://%s/%s", r.Host, r.Path)
+}
+
+func (r Repository) Tag(version *semver.Version) string {
+	if r.Subdir == "" {
+		return version.Original()
+	}
+	return r.Subdir + "/" + version.Original()
+}
+
+// ChangelogPaths lists where a module's changelog may be: in its subdirectory of the repository, then at the root.
+func (r Repository) ChangelogPaths() []string {
+	if r.Subdir == "" {
+		return []string{changelogFilename}
+	}
+	return []string{r.Subdir + "/" + changelogFilename, changelogFilename}
+}
+
+// repoChangelogNotes reads the excerpt for module from the first of the repository's changelogs that has one, using
+// fetch to read a file by its path in the repository.
+func repoChangelogNotes(repo Repository, module Module, fetch func(path string) (string, error)) (string, error) {
+	var failures []string
+	for _, path := range repo.ChangelogPaths() {
+		content, err := fetch(path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("fetching %s: %s", path, err))
+			continue
+		}
+
+		notes, err := changelogNotes(path, content, module)
+		if err == nil {
+			return notes, nil
+		}
+		failures = append(failures, err.Error())
+	}
+
+	return "", errors.New(strings.Join(failures, "; "))
+}
+
+type ForgeProvider interface {
+	ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error)
+}
+
+type ProviderRegistry struct {
+	Resolver  *RepoResolver
+	providers map[Forge][]ForgeProvider
+}
+
+func NewProviderRegistry(resolver *RepoResolver) *ProviderRegistry {
+	return &ProviderRegistry{
+		Resolver:  resolver,
+		providers: map[Forge][]ForgeProvider{},
+	}
+}
+
+func (r *ProviderRegistry) Register(forge Forge, providers ...ForgeProvider) {
+	r.providers[forge] = append(r.providers[forge], providers...)
+}
+
+func (r *ProviderRegistry) ReleaseNotes(ctx context.Context, module Module) (string, error) {
+	repo, err := r.Resolver.Resolve(ctx, module.SourcePath())
+	if err != nil {
+		return "", fmt.Errorf("resolving repository: %w", err)
+	}
+
+	providers := r.providers[repo.Forge]
+	if len(providers) == 0 {
+		return "", fmt.Errorf("no release notes providers registered for %s", repo.Forge)
+	}
+
+	var errs []string
+	for _, provider := range providers {
+		notes, err := provider.ReleaseNotes(ctx, repo, module)
+		if err == nil {
+			return notes, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	return "", errors.New(strings.Join(errs, "; "))
+}
+
+type Release struct {
+	Tag  string
+	Name string
+	Body string
+}
+
+func releasesInRange(releases []Release, repo Repository, from, to *semver.Version) []Release {
+	var result []Release
+	versions := map[string]*semver.Version{}
+	for _, release := range releases {
+		tag := release.Tag
+		if repo.Subdir != "" {
+			if !strings.HasPrefix(tag, repo.Subdir+"/") {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, repo.Subdir+"/")
+		}
+
+		version, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if from != nil && !version.GreaterThan(from) {
+			continue
+		}
+		if to != nil && version.GreaterThan(to) {
+			continue
+		}
+		versions[release.Tag] = version
+		result = append(result, release)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return versions[result[i].Tag].GreaterThan(versions[result[j].Tag])
+	})
+
+	return result
+}
+
+func renderReleases(releases []Release) string {
+	var result []string
+	for _, release := range releases {
+		heading := "## " + release.Tag
+		if release.Name != "" && release.Name != release.Tag {
+			heading = fmt.Sprintf("%s (%s)", heading, release.Name)
+		}
+		result = append(result, heading)
+
+		body := strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n"))
+		if body != "" {
+			result = append(result, body)
+		}
+	}
+
+	return strings.Join(result, "\n\n")
+}
+
+// newGetRequest builds a GET request that is cancelled with ctx.
+func newGetRequest(ctx context.Context, rawURL string, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing url %q: %w", rawURL, err)
+	}
+	if header != nil {
+		req.Header = header
+	}
+
+	return req, nil
+}
+
+func getJSON(client HTTPClient, req *http.Request, v interface{}) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make a request to %s: %w", req.URL.Path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %s for %s: %d", req.URL.Host, req.URL.Path, res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("unexpected response from %s: %w", req.URL.Host, err)
+	}
+
+	return nil
+}
+
+func getText(client HTTPClient, req *http.Request) (string, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make a request to %s: %w", req.URL.Path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status from %s for %s: %d", req.URL.Host, req.URL.Path, res.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response from %s: %w", req.URL.Host, err)
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ProviderRegistry_UsesProvidersForResolvedForge(t *testing.T) {
+	githubProvider := &MockForgeProvider{ReturnNotes: "github notes"}
+	gitlabProvider := &MockForgeProvider{ReturnNotes: "gitlab notes"}
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200,
+		`<meta name="go-import" content="gitlab.com/group/project git https://gitlab.com/group/project.git">`, nil)
+	registry := NewProviderRegistry(NewRepoResolver(mockClient))
+	registry.Register(ForgeGithub, githubProvider)
+	registry.Register(ForgeGitlab, gitlabProvider)
+
+	notes, err := registry.ReleaseNotes(context.Background(), Module{Name: "gitlab.com/group/project"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "gitlab notes", notes)
+	assert.Empty(t, githubProvider.Calls)
+	assert.Equal(t, []Repository{{Forge: ForgeGitlab, Host: "gitlab.com", Path: "group/project"}}, gitlabProvider.Calls)
+}
+
+func Test_ProviderRegistry_TriesProvidersInOrder(t *testing.T) {
+	registry := NewProviderRegistry(NewRepoResolver(NewMockHTTPClient()))
+	registry.Register(ForgeGithub,
+		&MockForgeProvider{ReturnError: fmt.Errorf("first error")},
+		&MockForgeProvider{ReturnNotes: "second notes"},
+	)
+
+	notes, err := registry.ReleaseNotes(context.Background(), newValidModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "second notes", notes)
+}
+
+func Test_ProviderRegistry_ReturnsErrorWhenNoProvidersForForge(t *testing.T) {
+	registry := NewProviderRegistry(NewRepoResolver(NewMockHTTPClient()))
+
+	_, err := registry.ReleaseNotes(context.Background(), Module{Name: "bitbucket.org/team/repo"})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "no release notes providers registered for bitbucket")
+}
+
+func Test_ProviderRegistry_ReturnsErrorWhenRepositoryCannotBeResolved(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenErrorIsReturned(fmt.Errorf("no such host"))
+	registry := NewProviderRegistry(NewRepoResolver(mockClient))
+
+	_, err := registry.ReleaseNotes(context.Background(), Module{Name: "example.com/vanity"})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "resolving repository: fetching go-import meta tags:")
+}
+
+func Test_ReleasesInRange_FiltersAndSortsReleases(t *testing.T) {
+	releases := []Release{
+		{Tag: "v1.0.0"},
+		{Tag: "v1.2.0"},
+		{Tag: "v1.1.0"},
+		{Tag: "v2.0.0"},
+		{Tag: "latest"},
+	}
+
+	result := releasesInRange(releases, Repository{}, semver.MustParse("v1.0.0"), semver.MustParse("v1.2.0"))
+
+	assert.Equal(t, []Release{{Tag: "v1.2.0"}, {Tag: "v1.1.0"}}, result)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type GiteaRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	Draft   bool   `json:"draft"`
+}
+
+type GiteaProvider struct {
+	HTTPClient HTTPClient
+}
+
+func NewGiteaProvider(client HTTPClient) *GiteaProvider {
+	return &GiteaProvider{
+		HTTPClient: client,
+	}
+}
+
+func (p *GiteaProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	notes, err := p.changelog(ctx, repo, module)
+	if err == nil {
+		return notes, nil
+	}
+
+	notes, releasesErr := p.releases(ctx, repo, module)
+	if releasesErr == nil {
+		return notes, nil
+	}
+
+	return "", fmt.Errorf("%s; %s", err, releasesErr)
+}
+
+func (p *GiteaProvider) changelog(ctx context.Context, repo Repository, module Module) (string, error) {
+	return repoChangelogNotes(repo, module, func(path string) (string, error) {
+		req, err := newGetRequest(ctx, fmt.Sprintf("%s/raw/%s", p.repoURL(repo), path), nil)
+		if err != nil {
+			return "", err
+		}
+		return getText(p.HTTPClient, req)
+	})
+}
+
+func (p *GiteaProvider) releases(ctx context.Context, repo Repository, module Module) (string, error) {
+	req, err := newGetRequest(ctx, fmt.Sprintf("%s/releases?limit=50", p.repoURL(repo)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var giteaReleases []GiteaRelease
+	if err := getJSON(p.HTTPClient, req, &giteaReleases); err != nil {
+		return "", fmt.Errorf("listing releases: %w", err)
+	}
+
+	var releases []Release
+	for _, release := range giteaReleases {
+		if release.Draft {
+			continue
+		}
+		releases = append(releases, Release{Tag: release.TagName, Name: release.Name, Body: release.Body})
+	}
+
+	releases = releasesInRange(releases, repo, module.FromVersion, module.ToVersion)
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no releases found between %s and %s", module.FromVersion, module.ToVersion)
+	}
+
+	return renderReleases(releases), nil
+}
+
+func (p *GiteaProvider) repoURL(repo Repository) string {
+	return fmt.Sprintf("https://%s/api/v1/repos/%s", repo.Host, repo.Path)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GiteaProvider_ReturnsChangelogExcerpt(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "## [1.1.0]\n- new\n## [1.0.0]\n- old\n", nil)
+	p := NewGiteaProvider(mockClient)
+
+	notes, err := p.ReleaseNotes(context.Background(), newGiteaRepository(), newGiteaModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## [1.1.0]\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://git.example.com/api/v1/repos/team/lib/raw/CHANGELOG.md", calls[0].URL.String())
+}
+
+func Test_GiteaProvider_ReadsChangelogOfNestedModulesThenRoot(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(200, "## [1.1.0]\n- new\n## [1.0.0]\n- old\n", nil),
+	)
+	p := NewGiteaProvider(mockClient)
+	repo := newGiteaRepository()
+	repo.Subdir = "sub"
+
+	notes, err := p.ReleaseNotes(context.Background(), repo, newGiteaModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## [1.1.0]\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://git.example.com/api/v1/repos/team/lib/raw/sub/CHANGELOG.md", calls[0].URL.String())
+	assert.Equal(t, "https://git.example.com/api/v1/repos/team/lib/raw/CHANGELOG.md", calls[1].URL.String())
+}
+
+func Test_GiteaProvider_FallsBackToReleases(t *testing.T) {
+	releases := []GiteaRelease{
+		{TagName: "v1.1.0", Body: "new"},
+		{TagName: "v1.0.1", Body: "draft", Draft: true},
+	}
+	body, err := json.Marshal(releases)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(200, string(body), nil),
+	)
+	p := NewGiteaProvider(mockClient)
+
+	notes, err := p.ReleaseNotes(context.Background(), newGiteaRepository(), newGiteaModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\nnew", notes)
+}
+
+func newGiteaRepository() Repository {
+	return Repository{Forge: ForgeGitea, Host: "git.example.com", Path: "team/lib"}
+}
+
+func newGiteaModule() Module {
+	return Module{
+		Name:        "git.example.com/team/lib",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+}
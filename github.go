@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	changelogFilename = "CHANGELOG.md"
+	githubAPIHost     = "api.github.com"
+	userAgent         = "gomo (https://github.com/frasercobb/gomo)"
+)
+
+type GithubFileSearchResponse struct {
+	TotalCount int    `json:"total_count"`
+	Items      []Item `json:"items"`
+}
+
+type Item struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+}
+
+type GithubRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type GithubCompareResponse struct {
+	Commits []GithubCommit `json:"commits"`
+}
+
+type GithubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+type GithubChangelogProvider struct {
+	HTTPClient HTTPClient
+	Token      string
+}
+
+func NewGithubChangelogProvider(client HTTPClient, token string) *GithubChangelogProvider {
+	return &GithubChangelogProvider{
+		HTTPClient: client,
+		Token:      token,
+	}
+}
+
+func (p *GithubChangelogProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	githubResp, err := searchGithubForChangelog(ctx, p.HTTPClient, p.Token, repo.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return repoChangelogNotes(repo, module, func(path string) (string, error) {
+		item, err := getChangelogItemFromGithubSearchResult(githubResp, path)
+		if err != nil {
+			return "", err
+		}
+		return p.fetchFileContent(ctx, item)
+	})
+}
+
+func (p *GithubChangelogProvider) fetchFileContent(ctx context.Context, item Item) (string, error) {
+	u, err := url.Parse(item.URL)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s url %q: %w", item.Path, item.URL, err)
+	}
+
+	req := newGithubRequest(ctx, u, p.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	content, err := getText(p.HTTPClient, req)
+	if err != nil {
+		return "", fmt.Errorf("fetching %s content: %w", item.Path, err)
+	}
+
+	return content, nil
+}
+
+type GithubReleasesProvider struct {
+	HTTPClient HTTPClient
+	Token      string
+}
+
+func NewGithubReleasesProvider(client HTTPClient, token string) *GithubReleasesProvider {
+	return &GithubReleasesProvider{
+		HTTPClient: client,
+		Token:      token,
+	}
+}
+
+func (p *GithubReleasesProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	var githubReleases []GithubRelease
+	err := p.getJSON(ctx, fmt.Sprintf("/repos/%s/releases", repo.Path), "per_page=100", &githubReleases)
+	if err != nil {
+		return "", fmt.Errorf("listing releases: %w", err)
+	}
+
+	var releases []Release
+	for _, release := range githubReleases {
+		if release.Draft {
+			continue
+		}
+		releases = append(releases, Release{Tag: release.TagName, Name: release.Name, Body: release.Body})
+	}
+
+	releases = releasesInRange(releases, repo, module.FromVersion, module.ToVersion)
+	if len(releases) > 0 {
+		return renderReleases(releases), nil
+	}
+
+	return p.compareCommits(ctx, repo, module.FromVersion, module.ToVersion)
+}
+
+func (p *GithubReleasesProvider) compareCommits(ctx context.Context, repo Repository, from, to *semver.Version) (string, error) {
+	if from == nil || to == nil {
+		return "", fmt.Errorf("no releases found and versions are unknown")
+	}
+
+	var compare GithubCompareResponse
+	fromTag, toTag := repo.Tag(from), repo.Tag(to)
+	path := fmt.Sprintf("/repos/%s/compare/%s...%s", repo.Path, fromTag, toTag)
+	if err := p.getJSON(ctx, path, "", &compare); err != nil {
+		return "", fmt.Errorf("comparing %s...%s: %w", fromTag, toTag, err)
+	}
+	if len(compare.Commits) == 0 {
+		return "", fmt.Errorf("no releases or commits found between %s and %s", fromTag, toTag)
+	}
+
+	return renderGithubCommits(fromTag, toTag, compare.Commits), nil
+}
+
+func (p *GithubReleasesProvider) getJSON(ctx context.Context, path string, rawQuery string, v interface{}) error {
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     githubAPIHost,
+		Path:     path,
+		RawQuery: rawQuery,
+	}
+
+	req := newGithubRequest(ctx, u, p.Token)
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make a request to %s: %w", req.URL.Path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return githubStatusError(req, res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("unexpected response from %s: %w", req.URL.Host, err)
+	}
+
+	return nil
+}
+
+func renderGithubCommits(fromTag, toTag string, commits []GithubCommit) string {
+	result := []string{fmt.Sprintf("## Commits %s...%s", fromTag, toTag), ""}
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		sha := commit.SHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		subject := strings.SplitN(commit.Commit.Message, "\n", 2)[0]
+		result = append(result, fmt.Sprintf("- %s %s", sha, subject))
+	}
+
+	return strings.Join(result, "\n")
+}
+
+func getChangelogItemFromGithubSearchResult(searchResponse *GithubFileSearchResponse, path string) (Item, error) {
+	for _, item := range searchResponse.Items {
+		if item.Path == path {
+			return item, nil
+		}
+	}
+
+	return Item{}, fmt.Errorf("failed to find %s", path)
+}
+
+func searchGithubForChangelog(ctx context.Context, client HTTPClient, token string, repo string) (*GithubFileSearchResponse, error) {
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     githubAPIHost,
+		Path:     "/search/code",
+		RawQuery: fmt.Sprintf("q=repo:%s%sfilename:CHANGELOG.md", repo, "+"),
+	}
+	req := newGithubRequest(ctx, u, token)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make a request for changelog: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, githubStatusError(req, res)
+	}
+
+	var githubResp GithubFileSearchResponse
+	decoder := json.NewDecoder(res.Body)
+	if err = decoder.Decode(&githubResp); err != nil {
+		return nil, fmt.Errorf("unexpected response from github API: %w", err)
+	}
+
+	return &githubResp, nil
+}
+
+// githubStatusError includes the message GitHub explains errors with, such as which rate limit was exceeded.
+func githubStatusError(req *http.Request, res *http.Response) error {
+	err := fmt.Errorf("unexpected status from %s for %s: %d", req.URL.Host, req.URL.Path, res.StatusCode)
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(res.Body).Decode(&body) == nil && body.Message != "" {
+		return fmt.Errorf("%w: %s", err, body.Message)
+	}
+	return err
+}
+
+func newGithubRequest(ctx context.Context, u *url.URL, token string) *http.Request {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	header.Set("User-Agent", userAgent)
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Header: header,
+	}
+	return req.WithContext(ctx)
+}
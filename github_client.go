@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	githubRetries    = 3
+	githubRetryDelay = time.Second
+	// githubMaxWait is the longest gomo waits for a rate limit to reset. The search limits reset every minute, while
+	// the core limit resets hourly, which is too long to wait.
+	githubMaxWait = time.Minute
+)
+
+// GithubRateLimit is the state of one of the GitHub API's rate limits, as reported by its latest response.
+type GithubRateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// GithubAPIClient sends requests to the GitHub API with the token, if any, and keeps track of its rate limits. Requests
+// that hit a rate limit are retried once it resets, if that's within MaxWait, and requests are not sent at all while a
+// limit is exhausted for longer. Server errors and failed connections are retried after RetryDelay, doubling each time.
+// Requests to other hosts are passed through.
+type GithubAPIClient struct {
+	Client     HTTPClient
+	Token      string
+	Retries    int
+	RetryDelay time.Duration
+	MaxWait    time.Duration
+
+	mu     sync.Mutex
+	limits map[string]GithubRateLimit
+}
+
+func NewGithubAPIClient(client HTTPClient, token string) *GithubAPIClient {
+	return &GithubAPIClient{
+		Client:     client,
+		Token:      token,
+		Retries:    githubRetries,
+		RetryDelay: githubRetryDelay,
+		MaxWait:    githubMaxWait,
+		limits:     map[string]GithubRateLimit{},
+	}
+}
+
+func (c *GithubAPIClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != githubAPIHost {
+		return c.Client.Do(req)
+	}
+	if c.Token != "" && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	resource := githubResource(req.URL.Path)
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForLimit(req.Context(), resource); err != nil {
+			return nil, err
+		}
+
+		res, err := c.Client.Do(req)
+		wait, retry := delay, err != nil
+		if err == nil {
+			c.record(resource, res.Header)
+			wait, retry = c.retryAfter(res, delay)
+		}
+		if !retry || attempt >= c.Retries {
+			return res, err
+		}
+
+		if res != nil {
+			res.Body.Close()
+		}
+		if wait > 0 {
+			if err := sleep(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+		delay *= 2
+	}
+}
+
+// retryAfter decides whether a response is worth retrying, and how long to wait before doing so.
+func (c *GithubAPIClient) retryAfter(res *http.Response, delay time.Duration) (time.Duration, bool) {
+	switch {
+	case res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests:
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= c.MaxWait
+		}
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			// waitForLimit waits for the reset before the retry.
+			return 0, parseRateLimitReset(res.Header).Sub(now()) <= c.MaxWait
+		}
+		return 0, false
+	case res.StatusCode >= http.StatusInternalServerError:
+		return delay, true
+	default:
+		return 0, false
+	}
+}
+
+// waitForLimit waits for an exhausted rate limit to reset, or fails when that would take longer than MaxWait.
+func (c *GithubAPIClient) waitForLimit(ctx context.Context, resource string) error {
+	c.mu.Lock()
+	limit, ok := c.limits[resource]
+	c.mu.Unlock()
+	if !ok || limit.Remaining > 0 {
+		return nil
+	}
+
+	wait := limit.Reset.Sub(now())
+	if wait <= 0 {
+		return nil
+	}
+	if wait > c.MaxWait {
+		return fmt.Errorf("the GitHub API %s rate limit is exhausted until %s", resource, limit.Reset.Format("15:04"))
+	}
+	return sleep(ctx, wait)
+}
+
+func (c *GithubAPIClient) record(resource string, header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits[resource] = GithubRateLimit{Limit: limit, Remaining: remaining, Reset: parseRateLimitReset(header)}
+}
+
+// RateLimitWarnings describes the rate limits that have run out or are running low.
+func (c *GithubAPIClient) RateLimitWarnings() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var resources []string
+	for resource := range c.limits {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	var warnings []string
+	for _, resource := range resources {
+		limit := c.limits[resource]
+		if !limit.Reset.After(now()) {
+			continue
+		}
+
+		reset := limit.Reset.Format("15:04")
+		switch {
+		case limit.Remaining == 0:
+			warnings = append(warnings, fmt.Sprintf(
+				"the GitHub API %s rate limit is exhausted until %s, so some release notes are missing", resource, reset))
+		case limit.Remaining < limit.Limit/10:
+			warnings = append(warnings, fmt.Sprintf("only %d of %d GitHub API %s requests are left until %s",
+				limit.Remaining, limit.Limit, resource, reset))
+		default:
+			continue
+		}
+		if c.Token == "" {
+			warnings[len(warnings)-1] += "; set GITHUB_TOKEN or GH_TOKEN to raise the limit"
+		}
+	}
+
+	return warnings
+}
+
+// githubResource names the rate limit that applies to an API path, as GitHub limits searches separately.
+func githubResource(path string) string {
+	switch {
+	case path == "/search/code":
+		return "code_search"
+	case strings.HasPrefix(path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+func parseRateLimitReset(header http.Header) time.Time {
+	seconds, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var githubTestNow = time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func rateLimitHeader(limit int, remaining int, reset time.Time) http.Header {
+	return http.Header{
+		"X-Ratelimit-Limit":     []string{strconv.Itoa(limit)},
+		"X-Ratelimit-Remaining": []string{strconv.Itoa(remaining)},
+		"X-Ratelimit-Reset":     []string{strconv.FormatInt(reset.Unix(), 10)},
+	}
+}
+
+func doGithubRequest(t *testing.T, client HTTPClient, rawURL string) (*http.Response, error) {
+	req, err := newGetRequest(context.Background(), rawURL, nil)
+	require.NoError(t, err)
+	return client.Do(req)
+}
+
+func Test_GithubAPIClient_PassesOtherHostsThrough(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(500, "", nil)
+	c := NewGithubAPIClient(mockClient, "a-token")
+
+	res, err := doGithubRequest(t, c, "https://gitlab.com/api/v4/projects")
+	require.NoError(t, err)
+
+	assert.Equal(t, 500, res.StatusCode)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Empty(t, calls[0].Header.Get("Authorization"))
+}
+
+func Test_GithubAPIClient_AddsTokenUnlessAlreadySet(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "{}", nil)
+	c := NewGithubAPIClient(mockClient, "a-token")
+
+	_, err := doGithubRequest(t, c, "https://api.github.com/search/code?q=repo:foo/bar")
+	require.NoError(t, err)
+	req, err := newGetRequest(context.Background(), "https://api.github.com/repos/foo/bar", http.Header{"Authorization": []string{"token other-token"}})
+	require.NoError(t, err)
+	_, err = c.Do(req)
+	require.NoError(t, err)
+
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "token a-token", calls[0].Header.Get("Authorization"))
+	assert.Equal(t, "token other-token", calls[1].Header.Get("Authorization"))
+}
+
+func Test_GithubAPIClient_RetriesAfterRetryAfter(t *testing.T) {
+	givenNow(t, githubTestNow)
+	sleeps := givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(403, `{"message":"You have exceeded a secondary rate limit."}`,
+			http.Header{"Retry-After": []string{"30"}}),
+		newMockResponse(200, "{}", nil),
+	)
+	c := NewGithubAPIClient(mockClient, "")
+
+	res, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, []time.Duration{30 * time.Second}, *sleeps)
+}
+
+func Test_GithubAPIClient_WaitsForShortRateLimitResets(t *testing.T) {
+	givenNow(t, githubTestNow)
+	sleeps := givenSleep(t)
+	reset := githubTestNow.Add(20 * time.Second)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(403, `{"message":"API rate limit exceeded"}`, rateLimitHeader(10, 0, reset)),
+		newMockResponse(200, "{}", rateLimitHeader(10, 9, reset.Add(time.Minute))),
+	)
+	c := NewGithubAPIClient(mockClient, "")
+
+	res, err := doGithubRequest(t, c, "https://api.github.com/search/code?q=repo:foo/bar")
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, []time.Duration{20 * time.Second}, *sleeps)
+}
+
+func Test_GithubAPIClient_StopsSendingRequestsWhileRateLimitIsExhausted(t *testing.T) {
+	givenNow(t, githubTestNow)
+	sleeps := givenSleep(t)
+	reset := githubTestNow.Add(30 * time.Minute)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(403, `{"message":"API rate limit exceeded"}`, rateLimitHeader(60, 0, reset))
+	c := NewGithubAPIClient(mockClient, "")
+
+	res, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+	require.NoError(t, err)
+	assert.Equal(t, 403, res.StatusCode)
+
+	_, err = doGithubRequest(t, c, "https://api.github.com/repos/foo/baz/releases")
+	assert.EqualError(t, err, "the GitHub API core rate limit is exhausted until "+reset.Local().Format("15:04"))
+
+	// Searches have their own limit.
+	_, err = doGithubRequest(t, c, "https://api.github.com/search/code?q=repo:foo/bar")
+	require.NoError(t, err)
+
+	assert.Len(t, mockClient.GetCalls(), 2)
+	assert.Empty(t, *sleeps)
+}
+
+func Test_GithubAPIClient_RetriesServerErrorsWithBackoff(t *testing.T) {
+	sleeps := givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(502, "", nil)
+	c := NewGithubAPIClient(mockClient, "")
+
+	res, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+	require.NoError(t, err)
+
+	assert.Equal(t, 502, res.StatusCode)
+	assert.Len(t, mockClient.GetCalls(), githubRetries+1)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, *sleeps)
+}
+
+func Test_GithubAPIClient_StopsRetryingWhenContextIsCancelled(t *testing.T) {
+	givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(502, "", nil)
+	c := NewGithubAPIClient(mockClient, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := newGetRequest(ctx, "https://api.github.com/repos/foo/bar/releases", nil)
+	require.NoError(t, err)
+
+	_, err = c.Do(req)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, mockClient.GetCalls(), 1)
+}
+
+func Test_GithubAPIClient_RetriesFailedConnections(t *testing.T) {
+	givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenErrorIsReturned(fmt.Errorf("connection reset"))
+	c := NewGithubAPIClient(mockClient, "")
+
+	_, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+
+	assert.EqualError(t, err, "connection reset")
+	assert.Len(t, mockClient.GetCalls(), githubRetries+1)
+}
+
+func Test_GithubAPIClient_WarnsAboutExhaustedAndLowRateLimits(t *testing.T) {
+	givenNow(t, githubTestNow)
+	reset := githubTestNow.Add(30 * time.Minute)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "{}", rateLimitHeader(60, 3, reset)),
+		newMockResponse(403, "{}", rateLimitHeader(10, 0, reset)),
+	)
+	c := NewGithubAPIClient(mockClient, "")
+	c.MaxWait = 0
+
+	_, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+	require.NoError(t, err)
+	_, err = doGithubRequest(t, c, "https://api.github.com/search/code?q=repo:foo/bar")
+	require.NoError(t, err)
+
+	clock := reset.Local().Format("15:04")
+	assert.Equal(t, []string{
+		"the GitHub API code_search rate limit is exhausted until " + clock +
+			", so some release notes are missing; set GITHUB_TOKEN or GH_TOKEN to raise the limit",
+		"only 3 of 60 GitHub API core requests are left until " + clock +
+			"; set GITHUB_TOKEN or GH_TOKEN to raise the limit",
+	}, c.RateLimitWarnings())
+}
+
+func Test_GithubAPIClient_DoesNotWarnAboutHealthyOrResetLimits(t *testing.T) {
+	givenNow(t, githubTestNow)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "{}", rateLimitHeader(5000, 4000, githubTestNow.Add(time.Hour))),
+		newMockResponse(200, "{}", rateLimitHeader(30, 0, githubTestNow.Add(-time.Second))),
+	)
+	c := NewGithubAPIClient(mockClient, "a-token")
+
+	_, err := doGithubRequest(t, c, "https://api.github.com/repos/foo/bar/releases")
+	require.NoError(t, err)
+	_, err = doGithubRequest(t, c, "https://api.github.com/search/code?q=repo:foo/bar")
+	require.NoError(t, err)
+
+	assert.Empty(t, c.RateLimitWarnings())
+}
+
+func Test_GithubResource_SeparatesSearchLimits(t *testing.T) {
+	assert.Equal(t, "code_search", githubResource("/search/code"))
+	assert.Equal(t, "search", githubResource("/search/commits"))
+	assert.Equal(t, "core", githubResource("/repos/foo/bar/releases"))
+}
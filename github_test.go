@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GithubChangelogProvider_ReturnsSectionsBetweenVersions(t *testing.T) {
+	module := newValidModule()
+	module.FromVersion = semver.MustParse("1.0.0")
+	module.ToVersion = semver.MustParse("1.1.0")
+	githubResponse := GithubFileSearchResponse{
+		TotalCount: 1,
+		Items: []Item{
+			{Name: "CHANGELOG.md", Path: "CHANGELOG.md", URL: "https://api.github.com/contents/CHANGELOG.md"},
+		},
+	}
+	body, err := json.Marshal(githubResponse)
+	require.NoError(t, err)
+	changelog := "## v1.1.0\n- new\n\n## v1.0.0\n- old\n"
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, string(body), nil),
+		newMockResponse(200, changelog, nil),
+	)
+	p := NewGithubChangelogProvider(mockClient, "")
+
+	excerpt, err := p.ReleaseNotes(context.Background(), newGithubRepository(), module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", excerpt)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, githubResponse.Items[0].URL, calls[1].URL.String())
+}
+
+func Test_GithubChangelogProvider_ReturnsErrorWhenContentRequestFails(t *testing.T) {
+	githubResponse := GithubFileSearchResponse{
+		TotalCount: 1,
+		Items: []Item{
+			{Name: "CHANGELOG.md", Path: "CHANGELOG.md", URL: "https://api.github.com/contents/CHANGELOG.md"},
+		},
+	}
+	body, err := json.Marshal(githubResponse)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(newMockResponse(200, string(body), nil))
+	p := NewGithubChangelogProvider(mockClient, "")
+
+	_, err = p.ReleaseNotes(context.Background(), newGithubRepository(), newValidModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "fetching CHANGELOG.md content: failed to make a request to /contents/CHANGELOG.md:")
+}
+
+func Test_GithubChangelogProvider_ReturnsErrorWithGithubMessageWhenSearchFails(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(403, `{"message":"API rate limit exceeded for 127.0.0.1."}`, nil)
+	p := NewGithubChangelogProvider(mockClient, "")
+
+	_, err := p.ReleaseNotes(context.Background(), newGithubRepository(), newValidModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(),
+		"unexpected status from api.github.com for /search/code: 403: API rate limit exceeded for 127.0.0.1.")
+}
+
+func Test_GithubChangelogProvider_SendsUserAgent(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "{}", nil)
+	p := NewGithubChangelogProvider(mockClient, "")
+
+	_, _ = p.ReleaseNotes(context.Background(), newGithubRepository(), newValidModule())
+
+	calls := mockClient.GetCalls()
+	require.NotEmpty(t, calls)
+	assert.Equal(t, userAgent, calls[0].Header.Get("User-Agent"))
+}
+
+func Test_GithubChangelogProvider_ReturnsErrorWhenNoEntriesInRange(t *testing.T) {
+	module := newValidModule()
+	module.FromVersion = semver.MustParse("1.0.0")
+	module.ToVersion = semver.MustParse("1.1.0")
+	githubResponse := GithubFileSearchResponse{
+		TotalCount: 1,
+		Items: []Item{
+			{Name: "CHANGELOG.md", Path: "CHANGELOG.md", URL: "https://api.github.com/contents/CHANGELOG.md"},
+		},
+	}
+	body, err := json.Marshal(githubResponse)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, string(body), nil),
+		newMockResponse(200, "## v1.0.0\n- old\n", nil),
+	)
+	p := NewGithubChangelogProvider(mockClient, "")
+
+	_, err = p.ReleaseNotes(context.Background(), newGithubRepository(), module)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "no CHANGELOG.md entries between 1.0.0 and 1.1.0")
+}
+
+func Test_GithubChangelogProvider_PrefersChangelogOfNestedModules(t *testing.T) {
+	module := newValidModule()
+	module.FromVersion = semver.MustParse("1.0.0")
+	module.ToVersion = semver.MustParse("1.1.0")
+	githubResponse := GithubFileSearchResponse{
+		TotalCount: 2,
+		Items: []Item{
+			{Name: "CHANGELOG.md", Path: "CHANGELOG.md", URL: "https://api.github.com/contents/CHANGELOG.md"},
+			{Name: "CHANGELOG.md", Path: "sub/CHANGELOG.md", URL: "https://api.github.com/contents/sub/CHANGELOG.md"},
+		},
+	}
+	body, err := json.Marshal(githubResponse)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, string(body), nil),
+		newMockResponse(200, "## v1.1.0\n- new\n## v1.0.0\n- old\n", nil),
+	)
+	p := NewGithubChangelogProvider(mockClient, "")
+	repo := newGithubRepository()
+	repo.Subdir = "sub"
+
+	notes, err := p.ReleaseNotes(context.Background(), repo, module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+	assert.Equal(t, "https://api.github.com/contents/sub/CHANGELOG.md", mockClient.GetCalls()[1].URL.String())
+}
+
+func Test_GithubReleasesProvider_ReturnsReleasesInRangeNewestFirst(t *testing.T) {
+	module := newValidModule()
+	module.FromVersion = semver.MustParse("v1.0.0")
+	module.ToVersion = semver.MustParse("v1.2.0")
+	releases := []GithubRelease{
+		{TagName: "v1.3.0", Body: "too new"},
+		{TagName: "v1.1.0", Body: "one"},
+		{TagName: "v1.2.0", Name: "Second", Body: "two"},
+		{TagName: "v1.0.0", Body: "current"},
+		{TagName: "v1.1.5", Body: "draft", Draft: true},
+		{TagName: "not-a-version", Body: "ignored"},
+	}
+	body, err := json.Marshal(releases)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(body), nil)
+	p := NewGithubReleasesProvider(mockClient, "")
+
+	notes, err := p.ReleaseNotes(context.Background(), newGithubRepository(), module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.2.0 (Second)\n\ntwo\n\n## v1.1.0\n\none", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://api.github.com/repos/project/repo/releases?per_page=100", calls[0].URL.String())
+}
+
+func Test_GithubReleasesProvider_FallsBackToComparingCommits(t *testing.T) {
+	module := newValidModule()
+	module.FromVersion = semver.MustParse("v1.0.0")
+	module.ToVersion = semver.MustParse("v1.1.0")
+	compare := GithubCompareResponse{Commits: []GithubCommit{{SHA: "abcdef123456"}, {SHA: "1234567890ab"}}}
+	compare.Commits[0].Commit.Message = "first change\n\nmore detail"
+	compare.Commits[1].Commit.Message = "second change"
+	body, err := json.Marshal(compare)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "[]", nil),
+		newMockResponse(200, string(body), nil),
+	)
+	p := NewGithubReleasesProvider(mockClient, "")
+
+	notes, err := p.ReleaseNotes(context.Background(), newGithubRepository(), module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## Commits v1.0.0...v1.1.0\n\n- 1234567 second change\n- abcdef1 first change", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "/repos/project/repo/compare/v1.0.0...v1.1.0", calls[1].URL.Path)
+}
+
+func Test_GithubReleasesProvider_ReturnsErrorOnUnexpectedStatus(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(404, "{}", nil)
+	p := NewGithubReleasesProvider(mockClient, "")
+
+	_, err := p.ReleaseNotes(context.Background(), newGithubRepository(), newValidModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "listing releases: unexpected status from api.github.com for /repos/project/repo/releases: 404")
+}
+
+func Test_GithubReleasesProvider_SendsToken(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "[]", nil)
+	p := NewGithubReleasesProvider(mockClient, "a-token")
+
+	_, _ = p.ReleaseNotes(context.Background(), newGithubRepository(), newValidModule())
+
+	calls := mockClient.GetCalls()
+	require.NotEmpty(t, calls)
+	assert.Equal(t, "token a-token", calls[0].Header.Get("Authorization"))
+}
+
+func Test_GithubReleasesProvider_UsesSubdirectoryTagsForNestedModules(t *testing.T) {
+	module := Module{
+		Name:        "github.com/project/repo/sub",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+	releases := []GithubRelease{
+		{TagName: "v1.1.0", Body: "root module"},
+		{TagName: "sub/v1.1.0", Body: "sub module"},
+	}
+	body, err := json.Marshal(releases)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(body), nil)
+	p := NewGithubReleasesProvider(mockClient, "")
+	repo := newGithubRepository()
+	repo.Subdir = "sub"
+
+	notes, err := p.ReleaseNotes(context.Background(), repo, module)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## sub/v1.1.0\n\nsub module", notes)
+}
+
+func newGithubRepository() Repository {
+	return Repository{
+		Forge: ForgeGithub,
+		Host:  "github.com",
+		Path:  "project/repo",
+	}
+}
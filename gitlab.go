@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+type GitlabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type GitlabProvider struct {
+	HTTPClient HTTPClient
+}
+
+func NewGitlabProvider(client HTTPClient) *GitlabProvider {
+	return &GitlabProvider{
+		HTTPClient: client,
+	}
+}
+
+func (p *GitlabProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	notes, err := p.changelog(ctx, repo, module)
+	if err == nil {
+		return notes, nil
+	}
+
+	notes, releasesErr := p.releases(ctx, repo, module)
+	if releasesErr == nil {
+		return notes, nil
+	}
+
+	return "", fmt.Errorf("%s; %s", err, releasesErr)
+}
+
+func (p *GitlabProvider) changelog(ctx context.Context, repo Repository, module Module) (string, error) {
+	return repoChangelogNotes(repo, module, func(path string) (string, error) {
+		req, err := newGetRequest(ctx, fmt.Sprintf("%s/repository/files/%s/raw?ref=HEAD", p.projectURL(repo), url.PathEscape(path)), nil)
+		if err != nil {
+			return "", err
+		}
+		return getText(p.HTTPClient, req)
+	})
+}
+
+func (p *GitlabProvider) releases(ctx context.Context, repo Repository, module Module) (string, error) {
+	req, err := newGetRequest(ctx, fmt.Sprintf("%s/releases?per_page=100", p.projectURL(repo)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var gitlabReleases []GitlabRelease
+	if err := getJSON(p.HTTPClient, req, &gitlabReleases); err != nil {
+		return "", fmt.Errorf("listing releases: %w", err)
+	}
+
+	var releases []Release
+	for _, release := range gitlabReleases {
+		releases = append(releases, Release{Tag: release.TagName, Name: release.Name, Body: release.Description})
+	}
+
+	releases = releasesInRange(releases, repo, module.FromVersion, module.ToVersion)
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no releases found between %s and %s", module.FromVersion, module.ToVersion)
+	}
+
+	return renderReleases(releases), nil
+}
+
+func (p *GitlabProvider) projectURL(repo Repository) string {
+	return fmt.Sprintf("https://%s/api/v4/projects/%s", repo.Host, url.PathEscape(repo.Path))
+}
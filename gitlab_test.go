@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GitlabProvider_ReturnsChangelogExcerpt(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "## v1.1.0\n- new\n## v1.0.0\n- old\n", nil)
+	p := NewGitlabProvider(mockClient)
+
+	notes, err := p.ReleaseNotes(context.Background(), newGitlabRepository(), newGitlabModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://gitlab.com/api/v4/projects/group%2Fproject/repository/files/CHANGELOG.md/raw?ref=HEAD", calls[0].URL.String())
+}
+
+func Test_GitlabProvider_ReadsChangelogOfNestedModulesThenRoot(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(200, "## v1.1.0\n- new\n## v1.0.0\n- old\n", nil),
+	)
+	p := NewGitlabProvider(mockClient)
+	repo := newGitlabRepository()
+	repo.Subdir = "sub"
+
+	notes, err := p.ReleaseNotes(context.Background(), repo, newGitlabModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://gitlab.com/api/v4/projects/group%2Fproject/repository/files/sub%2FCHANGELOG.md/raw?ref=HEAD", calls[0].URL.String())
+	assert.Equal(t, "https://gitlab.com/api/v4/projects/group%2Fproject/repository/files/CHANGELOG.md/raw?ref=HEAD", calls[1].URL.String())
+}
+
+func Test_GitlabProvider_FallsBackToReleases(t *testing.T) {
+	releases := []GitlabRelease{
+		{TagName: "v1.1.0", Description: "new"},
+		{TagName: "v1.0.0", Description: "old"},
+	}
+	body, err := json.Marshal(releases)
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(200, string(body), nil),
+	)
+	p := NewGitlabProvider(mockClient)
+
+	notes, err := p.ReleaseNotes(context.Background(), newGitlabRepository(), newGitlabModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\nnew", notes)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://gitlab.com/api/v4/projects/group%2Fproject/releases?per_page=100", calls[1].URL.String())
+}
+
+func Test_GitlabProvider_ReturnsBothErrors(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "", nil),
+		newMockResponse(404, "", nil),
+	)
+	p := NewGitlabProvider(mockClient)
+
+	_, err := p.ReleaseNotes(context.Background(), newGitlabRepository(), newGitlabModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "fetching CHANGELOG.md: ")
+	assert.Contains(t, err.Error(), "; listing releases: ")
+}
+
+func newGitlabRepository() Repository {
+	return Repository{Forge: ForgeGitlab, Host: "gitlab.com", Path: "group/project"}
+}
+
+func newGitlabModule() Module {
+	return Module{
+		Name:        "gitlab.com/group/project",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 type MockHTTPClient struct {
-	returnResponse *http.Response
-	returnError    error
-	calls          []*http.Request
+	returnResponse  *http.Response
+	returnError     error
+	queuedResponses []*http.Response
+	calls           []*http.Request
+	mu              sync.Mutex
 }
 
 func NewMockHTTPClient() *MockHTTPClient {
@@ -22,11 +25,20 @@ func NewMockHTTPClient() *MockHTTPClient {
 }
 
 func (c *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.calls = append(c.calls, req)
+	if len(c.queuedResponses) > 0 {
+		response := c.queuedResponses[0]
+		c.queuedResponses = c.queuedResponses[1:]
+		return response, nil
+	}
 	return c.returnResponse, c.returnError
 }
 
 func (c *MockHTTPClient) GetCalls() []*http.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.calls
 }
 
@@ -36,8 +48,15 @@ func (c *MockHTTPClient) GivenErrorIsReturned(err error) {
 }
 
 func (c *MockHTTPClient) GivenResponseIsReturned(statusCode int, body string, header http.Header) {
-	bodyContent := ioutil.NopCloser(bytes.NewReader([]byte(body)))
-	response := &http.Response{Body: bodyContent, StatusCode: statusCode, Header: header}
-	c.returnResponse = response
+	c.returnResponse = newMockResponse(statusCode, body, header)
 	c.returnError = nil
 }
+
+func (c *MockHTTPClient) GivenResponsesAreReturnedInOrder(responses ...*http.Response) {
+	c.queuedResponses = append(c.queuedResponses, responses...)
+}
+
+func newMockResponse(statusCode int, body string, header http.Header) *http.Response {
+	bodyContent := ioutil.NopCloser(bytes.NewReader([]byte(body)))
+	return &http.Response{Body: bodyContent, StatusCode: statusCode, Header: header}
+}
@@ -1,54 +1,452 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 )
 
+const (
+	defaultCommandTimeout = 10 * time.Minute
+	proxyRetries          = 3
+	proxyRetryDelay       = time.Second
+)
+
+type cliOptions struct {
+	simple     bool
+	cooldown   int
+	prerelease bool
+	edit       bool
+	dryRun     bool
+	tidy       bool
+	goReleases string
+	tools      string
+	timeout    time.Duration
+	verbose    bool
+	dir        string
+	env        envFlag
+	record     string
+	replay     string
+	auto       string
+	answers    string
+	queryProxy bool
+	jobs       int
+	rateLimit  int
+	refresh    bool
+	cacheDir   string
+	// userConfig is the path of the user config, which defaults to gomo's directory in the user config directory.
+	userConfig string
+	// transport sends gomo's HTTP requests, defaulting to http.DefaultTransport.
+	transport http.RoundTripper
+}
+
+// envFlag collects repeated KEY=value flags.
+type envFlag []string
+
+func (e *envFlag) String() string {
+	return strings.Join(*e, " ")
+}
+
+func (e *envFlag) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected KEY=value, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
 func main() {
-	if err := run(); err != nil {
+	// Interrupting cancels the session, which interrupts any running go command and waits for it to exit. A second
+	// interrupt gets the default behaviour again, so that gomo can still be stopped if something ignores the first.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if len(os.Args) > 1 && os.Args[1] == "exclude" {
+		err := runExclude(os.Args[2:])
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Printf("Encountered an error %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		err := runCache(os.Args[2:])
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Printf("Encountered an error %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bin" {
+		err := runBin(ctx, os.Args[2:])
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Printf("Encountered an error %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts, err := parseFlags(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	p, err := newPrompter(opts.simple, opts.auto, opts.answers)
+	if err != nil {
+		fmt.Printf("Encountered an error %s\n", err)
+		os.Exit(2)
+	}
+	if err := run(ctx, opts, p); err != nil {
 		fmt.Printf("Encountered an error %s\n", err)
 	}
 }
 
-func run() error {
-	cmdExecutor := NewCommandExecutor()
-	client := http.Client{
-		Timeout: 2 * time.Second,
+func parseFlags(args []string) (cliOptions, error) {
+	var opts cliOptions
+	flags := flag.NewFlagSet("gomo", flag.ContinueOnError)
+	flags.BoolVar(&opts.simple, "simple", false, "use a simple multi-select prompt instead of the full-screen interface")
+	flags.IntVar(&opts.cooldown, "cooldown", 0, "only offer versions released at least this many days ago")
+	flags.BoolVar(&opts.prerelease, "prerelease", false, "offer prerelease versions as upgrade candidates")
+	flags.BoolVar(&opts.edit, "edit", false, "edit go.mod directly instead of running go get")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "print the go.mod changes instead of making them")
+	flags.BoolVar(&opts.tidy, "tidy", false, "run go mod tidy after editing go.mod with --edit")
+	flags.StringVar(&opts.goReleases, "go-releases", "",
+		"URL or file of the go.dev/dl JSON feed to find Go releases in, instead of the golang.org/toolchain module")
+	flags.DurationVar(&opts.timeout, "timeout", defaultCommandTimeout,
+		"how long each go command may run for before it is cancelled, or 0 for no limit")
+	flags.BoolVar(&opts.verbose, "verbose", false, "print the go commands gomo runs and their output as they run")
+	flags.StringVar(&opts.dir, "dir", "", "upgrade the module in this directory instead of the current one")
+	flags.Var(&opts.env, "env", "set an environment variable for the go command, as KEY=value; may be repeated")
+	flags.StringVar(&opts.record, "record", "", "record the go commands and HTTP requests of the session to this file")
+	flags.StringVar(&opts.replay, "replay", "", "replay a session recorded with --record instead of running commands")
+	flags.StringVar(&opts.auto, "auto", "",
+		"upgrade without asking, choosing updates of these comma-separated kinds (patch, minor, major, ..., all, go)")
+	flags.StringVar(&opts.answers, "answers", "",
+		"upgrade without asking, choosing the modules listed in this answer file, or - to read them from stdin")
+	flags.BoolVar(&opts.queryProxy, "query-proxy", false,
+		"find updates by querying GOPROXY directly, several modules at a time, instead of with go list -u")
+	flags.IntVar(&opts.jobs, "jobs", defaultWorkers, "how many modules --query-proxy looks up at a time")
+	flags.IntVar(&opts.rateLimit, "rate-limit", 0, "the most requests to make to GOPROXY per second, or 0 for no limit")
+	flags.BoolVar(&opts.refresh, "refresh", false, "check every cached lookup with its source instead of trusting it")
+	flags.StringVar(&opts.cacheDir, "cache-dir", "", "where to cache lookups, instead of gomo's directory in the user cache")
+	flags.StringVar(&opts.tools, "tools", toolsInclude,
+		"whether to offer tool dependencies alongside other modules (include), on their own (only) or not at all (skip)")
+
+	if err := flags.Parse(args); err != nil {
+		return cliOptions{}, err
 	}
-	d := NewDiscoverer(
-		WithExecutor(cmdExecutor),
-		WithHTTPClient(&client),
-	)
+	if opts.cooldown < 0 {
+		err := fmt.Errorf("cooldown must not be negative, got %d", opts.cooldown)
+		fmt.Fprintln(flags.Output(), err)
+		return cliOptions{}, err
+	}
+	if opts.tools != toolsInclude && opts.tools != toolsOnly && opts.tools != toolsSkip {
+		err := fmt.Errorf("tools must be one of %s, %s or %s, got %q", toolsInclude, toolsOnly, toolsSkip, opts.tools)
+		fmt.Fprintln(flags.Output(), err)
+		return cliOptions{}, err
+	}
+	if opts.jobs < 1 || opts.rateLimit < 0 {
+		err := fmt.Errorf("jobs must be positive and rate-limit must not be negative, got %d and %d", opts.jobs,
+			opts.rateLimit)
+		fmt.Fprintln(flags.Output(), err)
+		return cliOptions{}, err
+	}
+	if opts.auto != "" && opts.answers != "" {
+		err := fmt.Errorf("auto and answers can't be used together")
+		fmt.Fprintln(flags.Output(), err)
+		return cliOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// newPrompter picks how upgrades are chosen: by policy, from an answer script, or by asking the user.
+func newPrompter(simple bool, auto string, answers string) (Prompter, error) {
+	switch {
+	case auto != "":
+		p, err := NewPolicyPrompter(auto)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case answers != "":
+		script, err := LoadAnswers(answers)
+		if err != nil {
+			return nil, err
+		}
+		return NewScriptPrompter(script), nil
+	default:
+		return NewSurveyPrompter(WithFullScreen(!simple)), nil
+	}
+}
+
+func run(ctx context.Context, opts cliOptions, p Prompter) error {
+	modFilePath := filepath.Join(opts.dir, "go.mod")
+	configPath := filepath.Join(opts.dir, configFilename)
 
-	modules, err := d.GetModules()
+	config, err := loadConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("getting modules: %w", err)
+		return err
+	}
+	if opts.prerelease {
+		config.Prerelease = true
+	}
+	if opts.goReleases != "" {
+		config.GoReleases = opts.goReleases
+	}
+	userConfigPath := opts.userConfig
+	if userConfigPath == "" {
+		// Without a user config directory there is no token to read, which only lowers GitHub's rate limits.
+		userConfigPath, _ = defaultUserConfigPath()
+	}
+	var userConfig UserConfig
+	if userConfigPath != "" {
+		userConfig, err = loadUserConfig(userConfigPath)
+		if err != nil {
+			return err
+		}
 	}
 
-	if len(modules) == 0 {
-		fmt.Println("No modules can be upgraded")
-		return nil
+	executorOptions := []CommandExecutorOption{WithCommandTimeout(opts.timeout)}
+	if opts.verbose {
+		executorOptions = append(executorOptions, WithStreamOutput(os.Stderr))
+	}
+	var cmdExecutor Executor = NewCommandExecutor(executorOptions...)
+	github := NewGithubAPIClient(&http.Client{
+		Timeout:   2 * time.Second,
+		Transport: opts.transport,
+	}, githubToken(userConfig))
+	var client HTTPClient = github
+	var proxyClient HTTPClient = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: opts.transport,
+	}
+
+	switch {
+	case opts.replay != "":
+		fixture, err := LoadFixture(opts.replay)
+		if err != nil {
+			return err
+		}
+		replayClient := NewReplayHTTPClient(fixture)
+		cmdExecutor, client, proxyClient = NewReplayExecutor(fixture), replayClient, replayClient
+	case opts.record != "":
+		fixture := &Fixture{}
+		defer func() {
+			if err := fixture.Save(opts.record); err != nil {
+				fmt.Printf("Warning: saving the recording: %s\n", err)
+			}
+		}()
+		cmdExecutor = NewRecordingExecutor(cmdExecutor, fixture)
+		client, proxyClient = NewRecordingHTTPClient(client, fixture), NewRecordingHTTPClient(proxyClient, fixture)
+	default:
+		// Recordings and replays bypass the cache, so that every request is recorded and replays are self-contained.
+		cacheDir := opts.cacheDir
+		if cacheDir == "" {
+			cacheDir, err = defaultCacheDir()
+			if err != nil {
+				return err
+			}
+		}
+		client = NewCachingHTTPClient(client, cacheDir, opts.refresh)
+		proxyClient = NewCachingHTTPClient(proxyClient, cacheDir, opts.refresh)
 	}
 
-	p := NewPrompter()
-	modulesToUpgrade, err := p.AskForUpgrades(modules)
+	goEnv, err := getGoEnv(ctx, cmdExecutor, opts.dir, opts.env, "GOPROXY", "GOMODCACHE", "GOPATH", "GONOPROXY")
 	if err != nil {
-		return fmt.Errorf("asking for which modules to upgrade: %w", err)
+		return err
+	}
+	proxy := NewProxyClient(proxyClient, goEnv["GOPROXY"], modCacheDir(goEnv["GOMODCACHE"], goEnv["GOPATH"]),
+		WithRateLimit(opts.rateLimit),
+		WithRetries(proxyRetries, proxyRetryDelay),
+		WithNoProxy(goEnv["GONOPROXY"]),
+	)
+	if opts.queryProxy && len(proxy.Proxies) == 0 {
+		return fmt.Errorf("--query-proxy needs a GOPROXY other than direct or off, got %q", goEnv["GOPROXY"])
+	}
+
+	// The status line would be garbled by the output of --verbose, and is only useful on a terminal.
+	var progress *Progress
+	if !opts.verbose && isTerminal(os.Stderr) {
+		progress = NewProgress(os.Stderr)
+	}
+
+	var goReleases GoReleaseSource = NewToolchainModuleSource(proxy)
+	if config.GoReleases != "" {
+		goReleases = NewGoDownloadsFeed(proxyClient, config.GoReleases)
 	}
 
-	if len(modulesToUpgrade) == 0 {
-		fmt.Println("No modules selected")
-		return nil
+	discovererOptions := []DiscovererOption{
+		WithExecutor(cmdExecutor),
+		WithDir(opts.dir),
+		WithEnv(opts.env...),
+		WithProxy(proxy),
+		WithCooldown(time.Duration(opts.cooldown) * 24 * time.Hour),
+		WithConfig(config),
+		WithGoReleases(goReleases),
+		WithMainModFile(modFilePath),
+		WithReleaseNotesProviders(
+			NewModuleZipProvider(proxy),
+			newProviderRegistry(client, github.Token),
+		),
+		WithProgress(progress),
+	}
+	if opts.queryProxy {
+		discovererOptions = append(discovererOptions, WithProxyQueries(opts.jobs))
 	}
+	d := NewDiscoverer(discovererOptions...)
+
+	checker := NewAPIChecker(cmdExecutor, filepath.Join(opts.dir, "."))
+	checker.Env = opts.env
 
-	u := NewUpgrader(
+	upgraderOptions := []UpgraderOption{
 		WithUpgradeExecutor(cmdExecutor),
-	)
-	if err := u.UpgradeModules(modulesToUpgrade); err != nil {
-		return err
+		WithUpgradeDir(opts.dir),
+		WithUpgradeEnv(opts.env...),
+		WithDryRun(opts.dryRun),
+		WithTidy(opts.tidy),
 	}
+	if opts.edit || opts.dryRun {
+		upgraderOptions = append(upgraderOptions, WithModFile(modFilePath))
+	}
+	u := NewUpgrader(upgraderOptions...)
 
-	return nil
+	// Excluding a version only changes what can be offered, so look for the next acceptable versions afterwards.
+	for {
+		modules, err := findUpgrades(ctx, d, checker, opts.tools)
+		if err != nil {
+			return err
+		}
+		for _, warning := range github.RateLimitWarnings() {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+		if len(modules) == 0 {
+			fmt.Println("No modules can be upgraded")
+			return nil
+		}
+
+		choices, err := p.AskForUpgrades(modules)
+		if err != nil {
+			return fmt.Errorf("asking for which modules to upgrade: %w", err)
+		}
+
+		if len(choices.Upgrades) == 0 && len(choices.Exclusions) == 0 {
+			fmt.Println("No modules selected")
+			return nil
+		}
+
+		if opts.dryRun {
+			for _, exclusion := range choices.Exclusions {
+				fmt.Printf("Would exclude %s@%s: %s\n", exclusion.Module.SourcePath(), exclusion.Module.ToVersion.Original(),
+					exclusion.Reason)
+			}
+			return u.UpgradeModules(ctx, choices.Upgrades)
+		}
+
+		if err := applyExclusions(modFilePath, configPath, choices.Exclusions); err != nil {
+			return err
+		}
+		for _, exclusion := range choices.Exclusions {
+			d.Config.AddExclude(exclusion.Module.SourcePath(), exclusion.Module.ToVersion.Original(), exclusion.Reason)
+		}
+
+		if len(choices.Upgrades) > 0 {
+			if err := u.UpgradeModules(ctx, choices.Upgrades); err != nil {
+				return err
+			}
+		}
+
+		if len(choices.Exclusions) == 0 {
+			return nil
+		}
+	}
+}
+
+// findUpgrades discovers the modules that can be upgraded and adds their release notes and API changes.
+func findUpgrades(ctx context.Context, d *Discoverer, checker *APIChecker, tools string) ([]Module, error) {
+	d.Progress.Start()
+	defer d.Progress.Stop()
+
+	modules, err := d.GetModules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting modules: %w", err)
+	}
+	d.Progress.Stop()
+	for _, warning := range d.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	modules = filterTools(modules, tools)
+
+	d.Progress.Start()
+	d.Progress.Status("reading release notes and checking API changes", len(modules))
+	for i := range modules {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		d.Progress.Advance()
+		if modules[i].Directive != "" {
+			continue
+		}
+		if excerpt, err := d.GetReleaseNotes(ctx, modules[i]); err == nil {
+			modules[i].Changelog = excerpt
+		}
+		if changes, err := checker.Check(ctx, modules[i]); err == nil {
+			modules[i].APIChanges = changes
+		}
+	}
+
+	return modules, nil
+}
+
+// githubToken is the token to authenticate to the GitHub API with, from the environment or else the user config.
+func githubToken(config UserConfig) string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	return config.GithubToken
+}
+
+func newProviderRegistry(client HTTPClient, githubToken string) *ProviderRegistry {
+	registry := NewProviderRegistry(NewRepoResolver(client))
+	registry.Register(ForgeGithub,
+		NewGithubChangelogProvider(client, githubToken),
+		NewGithubReleasesProvider(client, githubToken),
+	)
+	registry.Register(ForgeGitlab, NewGitlabProvider(client))
+	registry.Register(ForgeBitbucket, NewBitbucketProvider(client))
+	registry.Register(ForgeGitea, NewGiteaProvider(client))
+
+	return registry
 }
@@ -1,16 +1,251 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func Test_EndToEndHappyPath(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping in short mode")
+var updateFixtures = flag.Bool("update", false, "record the fixtures in testdata again from the go command and network")
+
+func Test_FindUpgrades_ReplaysRecordedSession(t *testing.T) {
+	fixturePath := filepath.Join("testdata", "replay.json")
+	dir := filepath.Join("testdata", "replay")
+
+	fixture := &Fixture{}
+	var executor Executor
+	var client HTTPClient
+	if *updateFixtures {
+		executor = NewRecordingExecutor(NewCommandExecutor(), fixture)
+		client = NewRecordingHTTPClient(&http.Client{Timeout: 30 * time.Second}, fixture)
+	} else {
+		var err error
+		fixture, err = LoadFixture(fixturePath)
+		require.NoError(t, err)
+		executor = NewReplayExecutor(fixture)
+		client = NewReplayHTTPClient(fixture)
+	}
+
+	proxy := NewProxyClient(client, defaultGoProxy, "")
+	d := NewDiscoverer(
+		WithExecutor(executor),
+		WithDir(dir),
+		WithProxy(proxy),
+		WithGoReleases(NewToolchainModuleSource(proxy)),
+		WithMainModFile(filepath.Join(dir, "go.mod")),
+		WithReleaseNotesProviders(NewModuleZipProvider(proxy)),
+	)
+	checker := NewAPIChecker(executor, dir)
+
+	modules, err := findUpgrades(context.Background(), d, checker, toolsInclude)
+	require.NoError(t, err)
+	if *updateFixtures {
+		require.NoError(t, fixture.Save(fixturePath))
 	}
-	err := run()
 
-	assert.NoError(t, err)
+	require.Len(t, modules, 3)
+	assert.Equal(t, goDirective, modules[0].Directive)
+	assert.Equal(t, "github.com/Masterminds/semver/v3", modules[1].Name)
+	assert.Equal(t, "v3.5.0", modules[1].ToVersion.Original())
+	assert.NotEmpty(t, modules[1].Changelog)
+	assert.Equal(t, "github.com/fatih/color", modules[2].Name)
+	assert.Equal(t, "v1.19.0", modules[2].ToVersion.Original())
+}
+
+func Test_FindUpgrades_StopsWhenContextIsCancelled(t *testing.T) {
+	provider := &MockReleaseNotesProvider{}
+	executor := &MockExecutor{CommandOutput: "==START==example.com/module,v1.0.0,v1.1.0,,,==END=="}
+	d := NewDiscoverer(
+		WithExecutor(executor),
+		WithReleaseNotesProviders(provider),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := findUpgrades(ctx, d, NewAPIChecker(executor, ""), toolsInclude)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Empty(t, provider.Calls)
+}
+
+func Test_ParseFlags_ParsesSimple(t *testing.T) {
+	opts, err := parseFlags([]string{"--simple"})
+	require.NoError(t, err)
+
+	assert.True(t, opts.simple)
+}
+
+func Test_ParseFlags_ReturnsErrorForUnknownFlags(t *testing.T) {
+	_, err := parseFlags([]string{"--not-a-flag"})
+
+	assert.Error(t, err)
+}
+
+func Test_ParseFlags_ParsesCooldown(t *testing.T) {
+	opts, err := parseFlags([]string{"--cooldown", "7"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, opts.cooldown)
+}
+
+func Test_ParseFlags_ReturnsErrorForNegativeCooldown(t *testing.T) {
+	_, err := parseFlags([]string{"--cooldown", "-1"})
+
+	assert.Error(t, err)
+}
+
+func Test_ParseFlags_ParsesPrerelease(t *testing.T) {
+	opts, err := parseFlags([]string{"--prerelease"})
+	require.NoError(t, err)
+
+	assert.True(t, opts.prerelease)
+}
+
+func Test_ParseFlags_ParsesEditModes(t *testing.T) {
+	opts, err := parseFlags([]string{"--edit", "--dry-run", "--tidy"})
+	require.NoError(t, err)
+
+	assert.True(t, opts.edit)
+	assert.True(t, opts.dryRun)
+	assert.True(t, opts.tidy)
+}
+
+func Test_ParseFlags_ParsesTools(t *testing.T) {
+	opts, err := parseFlags([]string{})
+	require.NoError(t, err)
+	assert.Equal(t, toolsInclude, opts.tools)
+
+	opts, err = parseFlags([]string{"--tools", "only"})
+	require.NoError(t, err)
+	assert.Equal(t, toolsOnly, opts.tools)
+
+	_, err = parseFlags([]string{"--tools", "sometimes"})
+	assert.Error(t, err)
+}
+
+func Test_ParseFlags_ParsesPrompterChoice(t *testing.T) {
+	opts, err := parseFlags([]string{"--auto", "patch,minor"})
+	require.NoError(t, err)
+	assert.Equal(t, "patch,minor", opts.auto)
+
+	opts, err = parseFlags([]string{"--answers", "-"})
+	require.NoError(t, err)
+	assert.Equal(t, "-", opts.answers)
+
+	_, err = parseFlags([]string{"--auto", "patch", "--answers", "-"})
+	assert.Error(t, err)
+}
+
+func Test_NewPrompter_PicksPrompterFromFlags(t *testing.T) {
+	p, err := newPrompter(true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &SurveyPrompter{FullScreen: false}, p)
+
+	p, err = newPrompter(false, "patch", "")
+	require.NoError(t, err)
+	assert.IsType(t, &PolicyPrompter{}, p)
+
+	_, err = newPrompter(false, "sometimes", "")
+	assert.Error(t, err)
+
+	_, err = newPrompter(false, "", "testdata/does-not-exist")
+	assert.Error(t, err)
+}
+
+func Test_ParseFlags_ParsesProxyQueries(t *testing.T) {
+	opts, err := parseFlags([]string{})
+	require.NoError(t, err)
+	assert.False(t, opts.queryProxy)
+	assert.Equal(t, defaultWorkers, opts.jobs)
+	assert.Equal(t, 0, opts.rateLimit)
+
+	opts, err = parseFlags([]string{"--query-proxy", "--jobs", "16", "--rate-limit", "50"})
+	require.NoError(t, err)
+	assert.True(t, opts.queryProxy)
+	assert.Equal(t, 16, opts.jobs)
+	assert.Equal(t, 50, opts.rateLimit)
+
+	_, err = parseFlags([]string{"--jobs", "0"})
+	assert.Error(t, err)
+
+	_, err = parseFlags([]string{"--rate-limit", "-1"})
+	assert.Error(t, err)
+}
+
+func Test_ParseFlags_ParsesCacheOptions(t *testing.T) {
+	opts, err := parseFlags([]string{"--refresh", "--cache-dir", "/tmp/gomo"})
+	require.NoError(t, err)
+
+	assert.True(t, opts.refresh)
+	assert.Equal(t, "/tmp/gomo", opts.cacheDir)
+}
+
+func Test_ParseFlags_ParsesVerbose(t *testing.T) {
+	opts, err := parseFlags([]string{"--verbose"})
+	require.NoError(t, err)
+
+	assert.True(t, opts.verbose)
+}
+
+func Test_ParseFlags_ParsesRecordAndReplay(t *testing.T) {
+	opts, err := parseFlags([]string{"--record", "session.json", "--replay", "other.json"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "session.json", opts.record)
+	assert.Equal(t, "other.json", opts.replay)
+}
+
+func Test_ParseFlags_ParsesTimeout(t *testing.T) {
+	opts, err := parseFlags([]string{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultCommandTimeout, opts.timeout)
+
+	opts, err = parseFlags([]string{"--timeout", "30s"})
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, opts.timeout)
+}
+
+func Test_ParseFlags_ParsesDirAndRepeatedEnv(t *testing.T) {
+	opts, err := parseFlags([]string{"--dir", "services/api", "--env", "GOFLAGS=-mod=mod", "--env", "GOPRIVATE=example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "services/api", opts.dir)
+	assert.Equal(t, envFlag{"GOFLAGS=-mod=mod", "GOPRIVATE=example.com"}, opts.env)
+
+	_, err = parseFlags([]string{"--env", "GOFLAGS"})
+	assert.Error(t, err)
+}
+
+// givenEnv sets an environment variable for the duration of the test.
+func givenEnv(t *testing.T, name string, value string) {
+	original, ok := os.LookupEnv(name)
+	require.NoError(t, os.Setenv(name, value))
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(name, original)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func Test_GithubToken_PrefersEnvironmentOverUserConfig(t *testing.T) {
+	config := UserConfig{GithubToken: "config-token"}
+	givenEnv(t, "GITHUB_TOKEN", "")
+	givenEnv(t, "GH_TOKEN", "")
+	assert.Equal(t, "config-token", githubToken(config))
+
+	givenEnv(t, "GH_TOKEN", "gh-token")
+	assert.Equal(t, "gh-token", githubToken(config))
+
+	givenEnv(t, "GITHUB_TOKEN", "github-token")
+	assert.Equal(t, "github-token", githubToken(config))
 }
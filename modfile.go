@@ -0,0 +1,408 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ModFile edits go.mod files line by line, so that comments, blank lines and the formatting of untouched directives
+// are preserved.
+type ModFile struct {
+	Path  string
+	lines []string
+}
+
+type modDirective struct {
+	Verb    string
+	Args    []string
+	Comment string
+	Line    int
+	InBlock bool
+}
+
+func ReadModFile(path string) (*ModFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	f := parseModFile(content)
+	f.Path = path
+	return f, nil
+}
+
+func parseModFile(content []byte) *ModFile {
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return &ModFile{}
+	}
+	return &ModFile{lines: strings.Split(text, "\n")}
+}
+
+func (f *ModFile) Bytes() []byte {
+	return []byte(strings.Join(f.lines, "\n") + "\n")
+}
+
+// Write replaces the file atomically, so that an interrupted write can't leave a truncated go.mod behind.
+func (f *ModFile) Write() error {
+	return writeFileAtomic(f.Path, f.Bytes())
+}
+
+func (f *ModFile) directives() []modDirective {
+	var result []modDirective
+	block := ""
+	for i, line := range f.lines {
+		fields, comment := splitModLine(line)
+		switch {
+		case block != "" && len(fields) == 1 && fields[0] == ")":
+			block = ""
+		case block != "":
+			if len(fields) > 0 {
+				result = append(result, modDirective{Verb: block, Args: fields, Comment: comment, Line: i, InBlock: true})
+			}
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+		case len(fields) > 0:
+			result = append(result, modDirective{Verb: fields[0], Args: fields[1:], Comment: comment, Line: i})
+		}
+	}
+
+	return result
+}
+
+// splitModLine splits a go.mod line into its tokens and trailing comment the way the go command does: quoted strings
+// are single tokens, unquoted, and parentheses are tokens of their own even when attached to a keyword.
+func splitModLine(line string) ([]string, string) {
+	var fields []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return fields, strings.TrimSpace(line[i:])
+		case c == '(' || c == ')':
+			fields = append(fields, string(c))
+			i++
+		case c == '"' || c == '`':
+			end := quotedEnd(line, i)
+			token := line[i:end]
+			if unquoted, err := strconv.Unquote(token); err == nil {
+				token = unquoted
+			}
+			fields = append(fields, token)
+			i = end
+		default:
+			end := i
+			for end < len(line) && !strings.ContainsRune(" \t\r()\"`", rune(line[end])) &&
+				!strings.HasPrefix(line[end:], "//") {
+				end++
+			}
+			fields = append(fields, line[i:end])
+			i = end
+		}
+	}
+
+	return fields, ""
+}
+
+// quotedEnd returns the index just after the quoted string starting at line[start], or the end of the line if it isn't
+// terminated.
+func quotedEnd(line string, start int) int {
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quote == '"':
+			i++
+		case line[i] == quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// joinModArgs joins directive arguments, quoting those the go command would misread unquoted.
+func joinModArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\r()\"`") || strings.Contains(arg, "//") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Directive returns the argument of a single-argument directive such as go or toolchain, or "" if it's missing.
+func (f *ModFile) Directive(verb string) string {
+	for _, d := range f.directives() {
+		if d.Verb == verb && !d.InBlock && len(d.Args) == 1 {
+			return d.Args[0]
+		}
+	}
+	return ""
+}
+
+// SetDirective sets a single-argument directive, adding a missing one after the go or module directive.
+func (f *ModFile) SetDirective(verb string, value string) {
+	insertAt := len(f.lines)
+	for _, d := range f.directives() {
+		if d.Verb == verb && !d.InBlock && len(d.Args) == 1 {
+			f.setDirective(d, []string{value})
+			return
+		}
+		if (d.Verb == "module" || d.Verb == goDirective) && !d.InBlock {
+			insertAt = d.Line + 1
+		}
+	}
+
+	f.lines = append(f.lines[:insertAt], append([]string{"", verb + " " + value}, f.lines[insertAt:]...)...)
+}
+
+// Tools returns the packages of the tool directives.
+func (f *ModFile) Tools() []string {
+	var result []string
+	for _, d := range f.directives() {
+		if d.Verb == "tool" && len(d.Args) == 1 {
+			result = append(result, d.Args[0])
+		}
+	}
+	return result
+}
+
+// SetRequire sets the required version of a module, adding a requirement if there isn't one.
+func (f *ModFile) SetRequire(path string, version string) {
+	for _, d := range f.directives() {
+		if d.Verb == "require" && len(d.Args) >= 2 && d.Args[0] == path {
+			f.setDirective(d, []string{path, version})
+			return
+		}
+	}
+
+	f.addDirective("require", []string{path, version})
+}
+
+func (f *ModFile) AddExclude(path string, version string) {
+	for _, d := range f.directives() {
+		if d.Verb == "exclude" && len(d.Args) >= 2 && d.Args[0] == path && d.Args[1] == version {
+			return
+		}
+	}
+
+	f.addDirective("exclude", []string{path, version})
+}
+
+func (f *ModFile) DropExclude(path string, version string) {
+	f.dropDirectives(func(d modDirective) bool {
+		return d.Verb == "exclude" && len(d.Args) >= 2 && d.Args[0] == path && d.Args[1] == version
+	})
+}
+
+// AddReplace adds a replace directive. Like go mod edit, a replacement without an old version replaces every existing
+// replacement of the module.
+func (f *ModFile) AddReplace(oldPath, oldVersion, newPath, newVersion string) {
+	args := []string{oldPath}
+	if oldVersion != "" {
+		args = append(args, oldVersion)
+	}
+	args = append(args, "=>", newPath)
+	if newVersion != "" {
+		args = append(args, newVersion)
+	}
+
+	var existing []modDirective
+	for _, d := range f.directives() {
+		if d.Verb != "replace" || len(d.Args) == 0 || d.Args[0] != oldPath {
+			continue
+		}
+		if oldVersion == "" || replaceMatches(d.Args, oldPath, oldVersion) {
+			existing = append(existing, d)
+		}
+	}
+	if len(existing) == 0 {
+		f.addDirective("replace", args)
+		return
+	}
+
+	f.setDirective(existing[0], args)
+	f.dropDirectives(func(d modDirective) bool {
+		for _, other := range existing[1:] {
+			if d.Line == other.Line {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// DropReplace removes the replacement of a specific version of a module, or of every version when none is given.
+func (f *ModFile) DropReplace(oldPath string, oldVersion string) {
+	f.dropDirectives(func(d modDirective) bool {
+		if d.Verb != "replace" || len(d.Args) == 0 || d.Args[0] != oldPath {
+			return false
+		}
+		return oldVersion == "" || replaceMatches(d.Args, oldPath, oldVersion)
+	})
+}
+
+func replaceMatches(args []string, oldPath string, oldVersion string) bool {
+	if len(args) < 3 || args[0] != oldPath {
+		return false
+	}
+	if oldVersion == "" {
+		return args[1] == "=>"
+	}
+	return args[1] == oldVersion
+}
+
+func (f *ModFile) setDirective(d modDirective, args []string) {
+	line := joinModArgs(args)
+	if d.InBlock {
+		line = "\t" + line
+	} else {
+		line = d.Verb + " " + line
+	}
+	if d.Comment != "" {
+		line += " " + d.Comment
+	}
+
+	f.lines[d.Line] = line
+}
+
+// addDirective adds to the last block of the same verb, after the last single-line directive of the same verb, or
+// to the end of the file.
+func (f *ModFile) addDirective(verb string, args []string) {
+	line := joinModArgs(args)
+
+	insertAt, inBlock := -1, false
+	for _, d := range f.directives() {
+		if d.Verb == verb {
+			insertAt, inBlock = d.Line+1, d.InBlock
+		}
+	}
+	if insertAt < 0 {
+		f.lines = append(f.lines, "", verb+" "+line)
+		return
+	}
+
+	if inBlock {
+		line = "\t" + line
+	} else {
+		line = verb + " " + line
+	}
+	f.lines = append(f.lines[:insertAt], append([]string{line}, f.lines[insertAt:]...)...)
+}
+
+func (f *ModFile) dropDirectives(matches func(modDirective) bool) {
+	drop := map[int]bool{}
+	for _, d := range f.directives() {
+		if matches(d) {
+			drop[d.Line] = true
+		}
+	}
+	if len(drop) == 0 {
+		return
+	}
+
+	var lines []string
+	for i, line := range f.lines {
+		if !drop[i] {
+			lines = append(lines, line)
+		}
+	}
+	f.lines = removeEmptyBlocks(lines)
+}
+
+func removeEmptyBlocks(lines []string) []string {
+	var result []string
+	for i := 0; i < len(lines); i++ {
+		fields, _ := splitModLine(lines[i])
+		if len(fields) == 2 && fields[1] == "(" && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == ")" {
+			i++
+			if len(result) > 0 && strings.TrimSpace(result[len(result)-1]) == "" &&
+				(i+1 >= len(lines) || strings.TrimSpace(lines[i+1]) == "") {
+				result = result[:len(result)-1]
+			}
+			continue
+		}
+		result = append(result, lines[i])
+	}
+	for len(result) > 0 && strings.TrimSpace(result[len(result)-1]) == "" {
+		result = result[:len(result)-1]
+	}
+
+	return result
+}
+
+func writeFileAtomic(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", tmp.Name(), err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("setting mode of %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	return nil
+}
+
+// diffLines returns the lines removed from and added to old, prefixed with "-" and "+", in order.
+func diffLines(old []string, new []string) []string {
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var result []string
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			result = append(result, "-"+old[i])
+			i++
+		default:
+			result = append(result, "+"+new[j])
+			j++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModFile = `module example.com/main
+
+go 1.13
+
+// Pinned until the v2 migration.
+require (
+	example.com/a v1.0.0
+	example.com/b v1.2.0 // indirect
+)
+
+require example.com/c v0.1.0
+
+replace example.com/d => ../d
+`
+
+func Test_ModFile_SetRequireUpdatesVersionInBlockAndKeepsComments(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	f.SetRequire("example.com/b", "v1.3.0")
+
+	assert.Contains(t, string(f.Bytes()), "\texample.com/b v1.3.0 // indirect\n")
+	assert.Contains(t, string(f.Bytes()), "// Pinned until the v2 migration.\n")
+}
+
+func Test_ModFile_SetRequireUpdatesSingleLineRequire(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	f.SetRequire("example.com/c", "v0.2.0")
+
+	assert.Contains(t, string(f.Bytes()), "\nrequire example.com/c v0.2.0\n")
+}
+
+func Test_ModFile_SetRequireAddsMissingRequirement(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	f.SetRequire("example.com/new", "v1.0.0")
+
+	assert.Contains(t, string(f.Bytes()), "require example.com/c v0.1.0\nrequire example.com/new v1.0.0\n")
+}
+
+func Test_ModFile_AddsAndDropsExcludes(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	f.AddExclude("example.com/a", "v1.1.0")
+	f.AddExclude("example.com/a", "v1.1.0")
+	assert.Equal(t, testModFile+"\nexclude example.com/a v1.1.0\n", string(f.Bytes()))
+
+	f.DropExclude("example.com/a", "v1.1.0")
+	assert.Equal(t, testModFile, string(f.Bytes()))
+}
+
+func Test_ModFile_AddReplaceReplacesExistingReplacement(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	f.AddReplace("example.com/d", "", "example.com/fork", "v1.0.0")
+
+	assert.Contains(t, string(f.Bytes()), "\nreplace example.com/d => example.com/fork v1.0.0\n")
+	assert.NotContains(t, string(f.Bytes()), "../d")
+}
+
+func Test_ModFile_AddReplaceKeepsOtherVersions(t *testing.T) {
+	f := parseModFile([]byte("module example.com/main\n\nreplace example.com/d v1.0.0 => ../d\n"))
+
+	f.AddReplace("example.com/d", "v2.0.0", "../d2", "")
+
+	assert.Equal(t, "module example.com/main\n\nreplace example.com/d v1.0.0 => ../d\nreplace example.com/d v2.0.0 => ../d2\n",
+		string(f.Bytes()))
+}
+
+func Test_ModFile_DropReplaceRemovesEmptyBlocks(t *testing.T) {
+	f := parseModFile([]byte("module example.com/main\n\nreplace (\n\texample.com/d => ../d\n)\n"))
+
+	f.DropReplace("example.com/d", "")
+
+	assert.Equal(t, "module example.com/main\n", string(f.Bytes()))
+}
+
+func Test_ModFile_WriteReplacesFileAndKeepsMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-modfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "go.mod")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testModFile), 0600))
+
+	f, err := ReadModFile(path)
+	require.NoError(t, err)
+	f.SetRequire("example.com/a", "v1.1.0")
+	require.NoError(t, f.Write())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\texample.com/a v1.1.0\n")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func Test_DiffLines_ListsRemovedAndAddedLines(t *testing.T) {
+	diff := diffLines([]string{"a", "b", "c"}, []string{"a", "B", "c", "d"})
+
+	assert.Equal(t, []string{"-b", "+B", "+d"}, diff)
+}
+
+func Test_ModFile_SetDirectiveUpdatesOrAddsDirective(t *testing.T) {
+	f := parseModFile([]byte(testModFile))
+
+	assert.Equal(t, "1.13", f.Directive("go"))
+	assert.Equal(t, "", f.Directive("toolchain"))
+
+	f.SetDirective("go", "1.21.0")
+	f.SetDirective("toolchain", "go1.22.2")
+
+	assert.Contains(t, string(f.Bytes()), "module example.com/main\n\ngo 1.21.0\n\ntoolchain go1.22.2\n\n// Pinned")
+}
+
+func Test_ModFile_ToolsListsToolDirectives(t *testing.T) {
+	f := parseModFile([]byte("module example.com/main\n\ntool example.com/a/cmd/a\n\ntool (\n\texample.com/b\n)\n"))
+
+	assert.Equal(t, []string{"example.com/a/cmd/a", "example.com/b"}, f.Tools())
+}
+
+func Test_ModFile_ReadsQuotedArgumentsContainingSlashes(t *testing.T) {
+	f := parseModFile([]byte("module \"example.com/main\"\n\nreplace example.com/d => \"//server/share/d\" // local copy\n"))
+
+	f.AddReplace("example.com/e", "", "//server/share/e", "")
+
+	assert.Equal(t, "example.com/main", f.Directive("module"))
+	assert.Equal(t, "module \"example.com/main\"\n\nreplace example.com/d => \"//server/share/d\" // local copy\n"+
+		"replace example.com/e => \"//server/share/e\"\n", string(f.Bytes()))
+}
+
+func Test_ModFile_ReadsBlocksWithParenthesisAttachedToKeyword(t *testing.T) {
+	f := parseModFile([]byte("module example.com/main\n\nrequire(\n\texample.com/a v1.0.0\n)\n\nexclude(\n\texample.com/a v0.9.0\n)\n"))
+
+	f.SetRequire("example.com/a", "v1.1.0")
+	f.DropExclude("example.com/a", "v0.9.0")
+
+	assert.Equal(t, "module example.com/main\n\nrequire(\n\texample.com/a v1.1.0\n)\n", string(f.Bytes()))
+}
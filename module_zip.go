@@ -0,0 +1,83 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+var changelogFileNames = []string{"CHANGELOG", "CHANGES", "HISTORY", "NEWS"}
+
+type ModuleZipProvider struct {
+	Proxy *ProxyClient
+}
+
+func NewModuleZipProvider(proxy *ProxyClient) *ModuleZipProvider {
+	return &ModuleZipProvider{
+		Proxy: proxy,
+	}
+}
+
+func (p *ModuleZipProvider) ReleaseNotes(ctx context.Context, module Module) (string, error) {
+	if module.ToVersion == nil {
+		return "", fmt.Errorf("unknown target version for %q", module.Name)
+	}
+
+	zipReader, err := p.Proxy.Zip(ctx, module.SourcePath(), module.ToVersion.Original())
+	if err != nil {
+		return "", fmt.Errorf("fetching module zip: %w", err)
+	}
+
+	file := findChangelogInZip(zipReader, fmt.Sprintf("%s@%s/", module.SourcePath(), module.ToVersion.Original()))
+	if file == nil {
+		return "", fmt.Errorf("no changelog found in module zip for %s@%s", module.SourcePath(), module.ToVersion.Original())
+	}
+
+	content, err := readZipFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from module zip: %w", path.Base(file.Name), err)
+	}
+
+	return changelogNotes(path.Base(file.Name), content, module)
+}
+
+func findChangelogInZip(zipReader *zip.Reader, prefix string) *zip.File {
+	candidates := map[string]*zip.File{}
+	for _, file := range zipReader.File {
+		name := strings.TrimPrefix(file.Name, prefix)
+		if name == file.Name || strings.Contains(name, "/") {
+			continue
+		}
+
+		base := strings.ToUpper(strings.TrimSuffix(name, path.Ext(name)))
+		if _, ok := candidates[base]; !ok {
+			candidates[base] = file
+		}
+	}
+
+	for _, name := range changelogFileNames {
+		if file, ok := candidates[name]; ok {
+			return file
+		}
+	}
+
+	return nil
+}
+
+func readZipFile(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ModuleZipProvider_ReturnsChangelogExcerptFromZip(t *testing.T) {
+	content := newModuleZip(t, "example.com/mod@v1.1.0/", map[string]string{
+		"go.mod":           "module example.com/mod",
+		"CHANGES.txt":      "## v1.1.0\n- from changes\n",
+		"CHANGELOG.md":     "## v1.1.0\n- new\n## v1.0.0\n- old\n",
+		"sub/CHANGELOG.md": "## v1.1.0\n- nested\n",
+	})
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(content), nil)
+	p := NewModuleZipProvider(NewProxyClient(mockClient, "https://proxy.example.com", ""))
+
+	notes, err := p.ReleaseNotes(context.Background(), newZipModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## v1.1.0\n\n- new", notes)
+}
+
+func Test_ModuleZipProvider_FindsOtherChangelogNames(t *testing.T) {
+	content := newModuleZip(t, "example.com/mod@v1.1.0/", map[string]string{
+		"History": "## 1.1.0\n- from history\n",
+	})
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(content), nil)
+	p := NewModuleZipProvider(NewProxyClient(mockClient, "https://proxy.example.com", ""))
+
+	notes, err := p.ReleaseNotes(context.Background(), newZipModule())
+	require.NoError(t, err)
+
+	assert.Equal(t, "## 1.1.0\n\n- from history", notes)
+}
+
+func Test_ModuleZipProvider_ReturnsErrorWhenNoChangelogInZip(t *testing.T) {
+	content := newModuleZip(t, "example.com/mod@v1.1.0/", map[string]string{
+		"go.mod": "module example.com/mod",
+	})
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(content), nil)
+	p := NewModuleZipProvider(NewProxyClient(mockClient, "https://proxy.example.com", ""))
+
+	_, err := p.ReleaseNotes(context.Background(), newZipModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "no changelog found in module zip for example.com/mod@v1.1.0")
+}
+
+func Test_ModuleZipProvider_ReturnsErrorFromProxy(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(404, "", nil)
+	p := NewModuleZipProvider(NewProxyClient(mockClient, "https://proxy.example.com", ""))
+
+	_, err := p.ReleaseNotes(context.Background(), newZipModule())
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "fetching module zip: ")
+}
+
+func newZipModule() Module {
+	return Module{
+		Name:        "example.com/mod",
+		FromVersion: semver.MustParse("v1.0.0"),
+		ToVersion:   semver.MustParse("v1.1.0"),
+	}
+}
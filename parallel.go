@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultWorkers = 8
+
+// sleep waits for d, or until ctx is done, in which case it returns ctx's error.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parallel calls fn with every index below n on up to workers goroutines. It stops handing out indexes once ctx is
+// done, and returns ctx's error in that case.
+func parallel(ctx context.Context, n int, workers int, fn func(i int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	var err error
+send:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return err
+}
+
+// rateLimiter spaces out calls to Wait so that no more than a given number happen per second.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	current := now()
+	wait := l.next.Sub(current)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next = current.Add(wait + l.interval)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		return sleep(ctx, wait)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// givenSleep records how long the code under test sleeps for instead of sleeping.
+func givenSleep(t *testing.T) *[]time.Duration {
+	var mu sync.Mutex
+	var sleeps []time.Duration
+	original := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = original })
+	return &sleeps
+}
+
+func Test_Parallel_CallsEveryIndexOnBoundedWorkers(t *testing.T) {
+	var running, most int32
+	seen := make([]bool, 20)
+
+	err := parallel(context.Background(), len(seen), 3, func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&most)
+			if current <= previous || atomic.CompareAndSwapInt32(&most, previous, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		seen[i] = true
+		atomic.AddInt32(&running, -1)
+	})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, seen, false)
+	assert.LessOrEqual(t, most, int32(3))
+}
+
+func Test_Parallel_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+
+	err := parallel(ctx, 100, 1, func(i int) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Less(t, atomic.LoadInt32(&calls), int32(100))
+}
+
+func Test_RateLimiter_SpacesOutCalls(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	sleeps := givenSleep(t)
+	limiter := newRateLimiter(4)
+
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	assert.Equal(t, []time.Duration{250 * time.Millisecond, 500 * time.Millisecond}, *sleeps)
+}
+
+func Test_Sleep_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := sleep(ctx, time.Hour)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
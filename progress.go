@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const progressInterval = 100 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress shows a spinner and what gomo is doing on a terminal while it works. All of its methods do nothing on a
+// nil Progress, so that callers don't need to check whether progress is shown.
+type Progress struct {
+	Writer   io.Writer
+	Interval time.Duration
+
+	mu      sync.Mutex
+	status  string
+	done    int
+	total   int
+	frame   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewProgress(w io.Writer) *Progress {
+	return &Progress{
+		Writer:   w,
+		Interval: progressInterval,
+	}
+}
+
+// Start starts redrawing the status line until Stop is called.
+func (p *Progress) Start() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.stop, p.stopped = make(chan struct{}), make(chan struct{})
+
+	go func(stop chan struct{}, stopped chan struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			p.draw()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}(p.stop, p.stopped)
+}
+
+// Stop stops redrawing and clears the status line.
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	stop, stopped := p.stop, p.stopped
+	p.stop, p.stopped = nil, nil
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-stopped
+	fmt.Fprint(p.Writer, "\r\x1b[K")
+}
+
+// Status describes the current step. When total is positive, the step counts up to it as Advance is called.
+func (p *Progress) Status(status string, total int) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status, p.done, p.total = status, 0, total
+}
+
+func (p *Progress) Advance() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+}
+
+func (p *Progress) draw() {
+	p.mu.Lock()
+	line := p.render()
+	p.frame++
+	p.mu.Unlock()
+
+	fmt.Fprint(p.Writer, line)
+}
+
+func (p *Progress) render() string {
+	line := fmt.Sprintf("\r\x1b[K%s %s", spinnerFrames[p.frame%len(spinnerFrames)], p.status)
+	if p.total > 0 {
+		line += fmt.Sprintf(" (%d/%d)", p.done, p.total)
+	}
+	return line
+}
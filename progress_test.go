@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Progress_RendersStatusAndCount(t *testing.T) {
+	p := NewProgress(&bytes.Buffer{})
+
+	p.Status("looking up versions", 3)
+	p.Advance()
+	p.frame = 1
+
+	assert.Equal(t, "\r\x1b[K⠙ looking up versions (1/3)", p.render())
+}
+
+func Test_Progress_RendersStatusWithoutTotal(t *testing.T) {
+	p := NewProgress(&bytes.Buffer{})
+
+	p.Status("listing modules", 0)
+
+	assert.Equal(t, "\r\x1b[K⠋ listing modules", p.render())
+}
+
+func Test_Progress_DrawsUntilStoppedAndClearsLine(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf)
+	p.Interval = time.Millisecond
+	p.Status("listing modules", 0)
+
+	p.Start()
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+	p.Stop()
+
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "\r\x1b[K⠋ listing modules"), output)
+	assert.True(t, strings.HasSuffix(output, "listing modules\r\x1b[K"), output)
+}
+
+func Test_Progress_DoesNothingWhenNil(t *testing.T) {
+	var p *Progress
+
+	p.Start()
+	p.Status("listing modules", 1)
+	p.Advance()
+	p.Stop()
+}
@@ -2,70 +2,248 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 )
 
-type Prompter struct{}
+const maxChangelogLines = 15
 
-func NewPrompter() *Prompter {
-	return &Prompter{}
+// Prompter decides which of the discovered upgrades to make.
+type Prompter interface {
+	AskForUpgrades(modules []Module) (Choices, error)
 }
 
-func (p *Prompter) AskForUpgrades(modules []Module) ([]Module, error) {
+// SurveyPrompter asks the user interactively, in the full-screen interface or a multi-select prompt.
+type SurveyPrompter struct {
+	FullScreen bool
+}
+
+type SurveyPrompterOption func(*SurveyPrompter)
+
+// Choices are the modules to upgrade and the candidate versions marked as bad.
+type Choices struct {
+	Upgrades   []Module
+	Exclusions []Exclusion
+}
+
+func NewSurveyPrompter(options ...SurveyPrompterOption) *SurveyPrompter {
+	p := &SurveyPrompter{
+		FullScreen: true,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+func WithFullScreen(fullScreen bool) SurveyPrompterOption {
+	return func(p *SurveyPrompter) {
+		p.FullScreen = fullScreen
+	}
+}
+
+func (p *SurveyPrompter) AskForUpgrades(modules []Module) (Choices, error) {
+	if p.FullScreen && len(modules) > 0 && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		choices, err := runTUI(modules)
+		if err != nil {
+			return Choices{}, fmt.Errorf("unable to get module choices: %w", err)
+		}
+		return choices, nil
+	}
+
+	fmt.Print(renderChangelogs(modules))
+	fmt.Print(renderAPIChanges(modules))
+
 	options := createSelectOptions(modules)
 
+	var labels []string
+	for _, option := range options {
+		labels = append(labels, option.Label)
+	}
+
 	prompt := &survey.MultiSelect{
-		Message: "Which modules do you want to upgrade?",
-		Options: options,
+		Message: "Which modules do you want to upgrade? Selecting a heading selects its whole group.",
+		Options: labels,
 	}
 
 	var choices []int
 	if err := survey.AskOne(prompt, &choices); err != nil {
-		return nil, fmt.Errorf("unable to get module choices: %w", err)
+		return Choices{}, fmt.Errorf("unable to get module choices: %w", err)
 	}
 
-	var modulesToUpgrade []Module
-	for _, choice := range choices {
-		modulesToUpgrade = append(modulesToUpgrade, modules[choice])
-	}
+	return Choices{Upgrades: chosenModules(options, choices)}, nil
+}
 
-	return modulesToUpgrade, nil
+// selectOption is a line of the multi-select prompt. Group headings carry every module in their group.
+type selectOption struct {
+	Label   string
+	Modules []Module
 }
 
-func createSelectOptions(modules []Module) []string {
+func createSelectOptions(modules []Module) []selectOption {
 	color.NoColor = false // https://github.com/golang/go/issues/18153
-	var minorUpgrades []Module
-	var patchUpgrades []Module
+	var directives, tools []Module
+	groups := make([][]Module, numUpdateKinds)
 	for _, m := range modules {
-		if m.MinorUpgrade {
-			minorUpgrades = append(minorUpgrades, m)
+		switch {
+		case m.Directive != "":
+			directives = append(directives, m)
+		case m.Tool:
+			tools = append(tools, m)
+		default:
+			groups[m.Update] = append(groups[m.Update], m)
 		}
-		if m.PatchUpgrade {
-			patchUpgrades = append(patchUpgrades, m)
+	}
+	sort.SliceStable(tools, func(i, j int) bool { return tools[i].Update < tools[j].Update })
+
+	var result []selectOption
+	if len(directives) > 0 {
+		result = append(result, selectOption{Label: color.New(color.Bold).Sprint("Go version"), Modules: directives})
+		for _, mod := range directives {
+			result = append(result, selectOption{Label: moduleToSelectPrompt(mod), Modules: []Module{mod}})
 		}
 	}
+	for kind, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
 
-	var result []string
-	for _, mod := range patchUpgrades {
-		result = append(result, moduleToSelectPrompt(mod))
+		heading := color.New(color.Bold).Sprintf("%s updates", UpdateKind(kind))
+		result = append(result, selectOption{Label: heading, Modules: group})
+		for _, mod := range group {
+			result = append(result, selectOption{Label: moduleToSelectPrompt(mod), Modules: []Module{mod}})
+		}
+	}
+	if len(tools) > 0 {
+		result = append(result, selectOption{Label: color.New(color.Bold).Sprint("tool updates"), Modules: tools})
+		for _, mod := range tools {
+			result = append(result, selectOption{Label: moduleToSelectPrompt(mod), Modules: []Module{mod}})
+		}
 	}
-	for _, mod := range minorUpgrades {
-		result = append(result, moduleToSelectPrompt(mod))
+
+	return result
+}
+
+func chosenModules(options []selectOption, choices []int) []Module {
+	seen := map[string]bool{}
+	var result []Module
+	for _, choice := range choices {
+		for _, mod := range options[choice].Modules {
+			if !seen[mod.Name] {
+				seen[mod.Name] = true
+				result = append(result, mod)
+			}
+		}
 	}
 
 	return result
 }
 
+func updateColor(kind UpdateKind) *color.Color {
+	switch kind {
+	case UpdatePatch:
+		return color.New(color.FgGreen)
+	case UpdateMinor:
+		return color.New(color.FgBlue)
+	case UpdateMajor:
+		return color.New(color.FgYellow)
+	case UpdatePrerelease:
+		return color.New(color.FgCyan)
+	case UpdatePseudoVersion:
+		return color.New(color.FgMagenta)
+	case UpdateIncompatible:
+		return color.New(color.FgHiMagenta)
+	default:
+		return color.New(color.FgHiRed)
+	}
+}
+
 func moduleToSelectPrompt(mod Module) string {
-	result := fmt.Sprintf("%s %s -> %s", mod.Name, mod.FromVersion, mod.ToVersion)
-	if mod.PatchUpgrade {
-		result = color.GreenString(result)
+	result := updateColor(mod.Update).Sprintf("%s %s -> %s", mod.DisplayName(), mod.FromVersion, mod.ToVersion)
+	if mod.Label != "" {
+		result += fmt.Sprintf(" [%s]", mod.Label)
+	}
+
+	if !mod.ToTime.IsZero() {
+		result += fmt.Sprintf(" (released %s)", releaseAge(mod.ToTime))
+	}
+
+	if mod.RequiresGo != "" {
+		result += color.YellowString(" (requires go %s)", mod.RequiresGo)
 	}
 
-	if mod.MinorUpgrade {
-		result = color.BlueString(result)
+	if len(mod.APIChanges) > 0 {
+		result += color.RedString(" (%d incompatible API changes)", len(mod.APIChanges))
 	}
 	return result
 }
+
+func releaseAge(released time.Time) string {
+	age := now().Sub(released)
+	switch {
+	case age < time.Hour:
+		return "just now"
+	case age < 24*time.Hour:
+		return pluralise(int(age/time.Hour), "hour") + " ago"
+	default:
+		return pluralise(int(age/(24*time.Hour)), "day") + " ago"
+	}
+}
+
+func pluralise(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+func renderChangelogs(modules []Module) string {
+	color.NoColor = false // https://github.com/golang/go/issues/18153
+	var result strings.Builder
+	for _, mod := range modules {
+		if mod.Changelog == "" {
+			continue
+		}
+
+		result.WriteString(color.New(color.Bold).Sprintf("%s %s -> %s", mod.DisplayName(), mod.FromVersion, mod.ToVersion))
+		result.WriteString("\n")
+
+		lines := strings.Split(mod.Changelog, "\n")
+		if len(lines) > maxChangelogLines {
+			omitted := len(lines) - maxChangelogLines
+			lines = append(lines[:maxChangelogLines], fmt.Sprintf("... %d more lines", omitted))
+		}
+		for _, line := range lines {
+			result.WriteString("  " + line + "\n")
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+func renderAPIChanges(modules []Module) string {
+	color.NoColor = false // https://github.com/golang/go/issues/18153
+	var result strings.Builder
+	for _, mod := range modules {
+		if len(mod.APIChanges) == 0 {
+			continue
+		}
+
+		result.WriteString(color.RedString("%s %s -> %s breaks code that uses:", mod.DisplayName(), mod.FromVersion, mod.ToVersion))
+		result.WriteString("\n")
+		for _, change := range mod.APIChanges {
+			result.WriteString("  " + change.String() + "\n")
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
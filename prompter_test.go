@@ -1,7 +1,9 @@
 package main
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
@@ -10,15 +12,16 @@ import (
 func Test_CreateSelectOptions_ColoursPatch(t *testing.T) {
 	modules := []Module{
 		{
-			Name:         "frasercobb/gomo",
-			FromVersion:  semver.MustParse("1.2.3"),
-			ToVersion:    semver.MustParse("1.2.4"),
-			PatchUpgrade: true,
+			Name:        "frasercobb/gomo",
+			FromVersion: semver.MustParse("1.2.3"),
+			ToVersion:   semver.MustParse("1.2.4"),
+			Update:      UpdatePatch,
 		},
 	}
-	result := createSelectOptions(modules)
+	result := optionLabels(createSelectOptions(modules))
 
 	assert.Equal(t, []string{
+		"\x1b[1mpatch updates\x1b[0m",
 		"\x1b[32mfrasercobb/gomo 1.2.3 -> 1.2.4\x1b[0m",
 	}, result)
 }
@@ -26,15 +29,16 @@ func Test_CreateSelectOptions_ColoursPatch(t *testing.T) {
 func Test_CreateSelectOptions_ColoursMinor(t *testing.T) {
 	modules := []Module{
 		{
-			Name:         "foo/bar",
-			FromVersion:  semver.MustParse("0.1.0"),
-			ToVersion:    semver.MustParse("0.2.0"),
-			MinorUpgrade: true,
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("0.1.0"),
+			ToVersion:   semver.MustParse("0.2.0"),
+			Update:      UpdateMinor,
 		},
 	}
-	result := createSelectOptions(modules)
+	result := optionLabels(createSelectOptions(modules))
 
 	assert.Equal(t, []string{
+		"\x1b[1mminor updates\x1b[0m",
 		"\x1b[34mfoo/bar 0.1.0 -> 0.2.0\x1b[0m",
 	}, result)
 }
@@ -42,30 +46,241 @@ func Test_CreateSelectOptions_ColoursMinor(t *testing.T) {
 func Test_CreateSelectOptions_GroupsByUpgradeType(t *testing.T) {
 	modules := []Module{
 		{
-			Name:         "minor/upgrade",
-			FromVersion:  semver.MustParse("0.1.1"),
-			ToVersion:    semver.MustParse("0.2.1"),
-			MinorUpgrade: true,
+			Name:        "minor/upgrade",
+			FromVersion: semver.MustParse("0.1.1"),
+			ToVersion:   semver.MustParse("0.2.1"),
+			Update:      UpdateMinor,
 		},
 		{
-			Name:         "patch/upgrade",
-			FromVersion:  semver.MustParse("0.0.1"),
-			ToVersion:    semver.MustParse("0.0.2"),
-			PatchUpgrade: true,
+			Name:        "patch/upgrade",
+			FromVersion: semver.MustParse("0.0.1"),
+			ToVersion:   semver.MustParse("0.0.2"),
+			Update:      UpdatePatch,
 		},
 	}
-	result := createSelectOptions(modules)
+	result := optionLabels(createSelectOptions(modules))
 
 	assert.Equal(t, []string{
+		"\x1b[1mpatch updates\x1b[0m",
 		"\x1b[32mpatch/upgrade 0.0.1 -> 0.0.2\x1b[0m",
+		"\x1b[1mminor updates\x1b[0m",
 		"\x1b[34mminor/upgrade 0.1.1 -> 0.2.1\x1b[0m",
 	}, result)
 }
 
+func Test_CreateSelectOptions_IncludesEveryUpdateKind(t *testing.T) {
+	modules := []Module{
+		{Name: "a/downgrade", FromVersion: semver.MustParse("1.0.1"), ToVersion: semver.MustParse("1.0.0"), Update: UpdateDowngrade},
+		{Name: "a/major", FromVersion: semver.MustParse("1.9.3"), ToVersion: semver.MustParse("2.0.0"), Update: UpdateMajor},
+		{Name: "a/pseudo", FromVersion: semver.MustParse("1.0.0"), ToVersion: semver.MustParse("1.0.1-0.20200101000000-0123456789ab"), Update: UpdatePseudoVersion},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, []string{
+		"\x1b[1mmajor updates\x1b[0m",
+		"\x1b[33ma/major 1.9.3 -> 2.0.0\x1b[0m",
+		"\x1b[1mpseudo-version updates\x1b[0m",
+		"\x1b[35ma/pseudo 1.0.0 -> 1.0.1-0.20200101000000-0123456789ab\x1b[0m",
+		"\x1b[1mdowngrade updates\x1b[0m",
+		"\x1b[91ma/downgrade 1.0.1 -> 1.0.0\x1b[0m",
+	}, result)
+}
+
+func Test_ChosenModules_MapsChoicesToModulesAndExpandsHeadings(t *testing.T) {
+	modules := []Module{
+		{Name: "minor/one", Update: UpdateMinor},
+		{Name: "patch/one", Update: UpdatePatch},
+		{Name: "patch/two", Update: UpdatePatch},
+	}
+	options := createSelectOptions(modules)
+
+	chosen := chosenModules(options, []int{0, 2, 4})
+
+	assert.Equal(t, []string{"patch/one", "patch/two", "minor/one"}, moduleNames(chosen))
+}
+
+func optionLabels(options []selectOption) []string {
+	var labels []string
+	for _, option := range options {
+		labels = append(labels, option.Label)
+	}
+	return labels
+}
+
 func Test_AskForUpgrades_ReturnsErrorWhenNoModulesGiven(t *testing.T) {
-	p := NewPrompter()
+	p := NewSurveyPrompter()
 
 	_, err := p.AskForUpgrades([]Module{})
 
 	assert.Contains(t, err.Error(), "unable to get module choices: ")
 }
+
+func Test_RenderChangelogs_RendersModulesWithChangelogs(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("0.1.0"),
+			ToVersion:   semver.MustParse("0.2.0"),
+			Changelog:   "## v0.2.0\n- a change",
+		},
+		{
+			Name:        "no/changelog",
+			FromVersion: semver.MustParse("0.1.0"),
+			ToVersion:   semver.MustParse("0.2.0"),
+		},
+	}
+
+	result := renderChangelogs(modules)
+
+	assert.Equal(t, "\x1b[1mfoo/bar 0.1.0 -> 0.2.0\x1b[0m\n  ## v0.2.0\n  - a change\n\n", result)
+}
+
+func Test_RenderChangelogs_TruncatesLongChangelogs(t *testing.T) {
+	var lines []string
+	for i := 0; i < maxChangelogLines+5; i++ {
+		lines = append(lines, "- a change")
+	}
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("0.1.0"),
+			ToVersion:   semver.MustParse("0.2.0"),
+			Changelog:   strings.Join(lines, "\n"),
+		},
+	}
+
+	result := renderChangelogs(modules)
+
+	assert.Contains(t, result, "  ... 5 more lines\n")
+}
+
+func Test_CreateSelectOptions_FlagsIncompatibleAPIChanges(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("1.2.3"),
+			ToVersion:   semver.MustParse("1.2.4"),
+			Update:      UpdatePatch,
+			APIChanges:  []APIChange{{Package: "foo/bar", Identifier: "Do", Description: "removed func"}},
+		},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, []string{
+		"\x1b[1mpatch updates\x1b[0m",
+		"\x1b[32mfoo/bar 1.2.3 -> 1.2.4\x1b[0m\x1b[31m (1 incompatible API changes)\x1b[0m",
+	}, result)
+}
+
+func Test_CreateSelectOptions_ShowsReleaseAge(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC))
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("1.2.3"),
+			ToVersion:   semver.MustParse("1.2.4"),
+			ToTime:      time.Date(2020, 6, 7, 9, 0, 0, 0, time.UTC),
+			Update:      UpdatePatch,
+		},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, []string{
+		"\x1b[1mpatch updates\x1b[0m",
+		"\x1b[32mfoo/bar 1.2.3 -> 1.2.4\x1b[0m (released 3 days ago)",
+	}, result)
+}
+
+func Test_CreateSelectOptions_ShowsTargetLabel(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("v0.0.0-20200101000000-abcdefabcdef"),
+			ToVersion:   semver.MustParse("v0.0.0-20200601000000-0123456789ab"),
+			Update:      UpdatePseudoVersion,
+			Label:       latestCommitLabel,
+		},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, "\x1b[35mfoo/bar 0.0.0-20200101000000-abcdefabcdef -> 0.0.0-20200601000000-0123456789ab\x1b[0m"+
+		" [latest commit on the default branch]", result[1])
+}
+
+func Test_ReleaseAge_DescribesAgeInLargestUnit(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, "just now", releaseAge(time.Date(2020, 6, 10, 11, 30, 0, 0, time.UTC)))
+	assert.Equal(t, "1 hour ago", releaseAge(time.Date(2020, 6, 10, 11, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "5 hours ago", releaseAge(time.Date(2020, 6, 10, 7, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "1 day ago", releaseAge(time.Date(2020, 6, 9, 11, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "40 days ago", releaseAge(time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func Test_RenderAPIChanges_ListsChangesPerModule(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "foo/bar",
+			FromVersion: semver.MustParse("1.2.3"),
+			ToVersion:   semver.MustParse("1.2.4"),
+			APIChanges:  []APIChange{{Package: "foo/bar", Identifier: "Do", Description: "removed func"}},
+		},
+	}
+
+	result := renderAPIChanges(modules)
+
+	assert.Equal(t, "\x1b[31mfoo/bar 1.2.3 -> 1.2.4 breaks code that uses:\x1b[0m\n  foo/bar.Do: removed func\n\n", result)
+}
+
+func Test_CreateSelectOptions_ListsGoDirectivesFirstAndFlagsRequiredGo(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "example.com/a",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.0.1"),
+			Update:      UpdatePatch,
+			RequiresGo:  "1.22",
+		},
+		{
+			Name:        goDirective,
+			FromVersion: semver.MustParse("1.21.0"),
+			ToVersion:   semver.MustParse("1.22.2"),
+			Update:      UpdateMinor,
+			Directive:   goDirective,
+		},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, []string{
+		"\x1b[1mGo version\x1b[0m",
+		"\x1b[34mgo 1.21.0 -> 1.22.2\x1b[0m",
+		"\x1b[1mpatch updates\x1b[0m",
+		"\x1b[32mexample.com/a 1.0.0 -> 1.0.1\x1b[0m\x1b[33m (requires go 1.22)\x1b[0m",
+	}, result)
+}
+
+func Test_CreateSelectOptions_GroupsToolsSeparately(t *testing.T) {
+	modules := []Module{
+		{
+			Name:        "example.com/tool",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.1.0"),
+			Update:      UpdateMinor,
+			Tool:        true,
+		},
+		{
+			Name:        "example.com/runtime",
+			FromVersion: semver.MustParse("1.0.0"),
+			ToVersion:   semver.MustParse("1.1.0"),
+			Update:      UpdateMinor,
+		},
+	}
+	result := optionLabels(createSelectOptions(modules))
+
+	assert.Equal(t, []string{
+		"\x1b[1mminor updates\x1b[0m",
+		"\x1b[34mexample.com/runtime 1.0.0 -> 1.1.0\x1b[0m",
+		"\x1b[1mtool updates\x1b[0m",
+		"\x1b[34mexample.com/tool 1.0.0 -> 1.1.0\x1b[0m",
+	}, result)
+}
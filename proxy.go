@@ -0,0 +1,280 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const defaultGoProxy = "https://proxy.golang.org"
+
+type ModuleInfo struct {
+	Version string
+	Time    time.Time
+}
+
+type ProxyClient struct {
+	HTTPClient HTTPClient
+	Proxies    []string
+	ModCache   string
+	NoProxy    string
+	Retries    int
+	RetryDelay time.Duration
+
+	limiter *rateLimiter
+}
+
+type ProxyClientOption func(*ProxyClient)
+
+func NewProxyClient(client HTTPClient, goproxy string, modCache string, options ...ProxyClientOption) *ProxyClient {
+	p := &ProxyClient{
+		HTTPClient: client,
+		Proxies:    parseGoProxy(goproxy),
+		ModCache:   modCache,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// WithRateLimit limits the requests made to the proxy to perSecond. Zero means no limit.
+func WithRateLimit(perSecond int) ProxyClientOption {
+	return func(p *ProxyClient) {
+		p.limiter = nil
+		if perSecond > 0 {
+			p.limiter = newRateLimiter(perSecond)
+		}
+	}
+}
+
+// WithNoProxy sets the GONOPROXY patterns of private modules, which are never requested from the proxy so that it
+// doesn't learn about them. Their files are still read from the module cache.
+func WithNoProxy(patterns string) ProxyClientOption {
+	return func(p *ProxyClient) {
+		p.NoProxy = patterns
+	}
+}
+
+// WithRetries retries requests that fail to connect or that the proxy rejects as overloaded, waiting delay before
+// the first retry and twice as long before each one after that.
+func WithRetries(retries int, delay time.Duration) ProxyClientOption {
+	return func(p *ProxyClient) {
+		p.Retries = retries
+		p.RetryDelay = delay
+	}
+}
+
+func (p *ProxyClient) Zip(ctx context.Context, modulePath string, version string) (*zip.Reader, error) {
+	escapedPath, escapedVersion := escapeModulePath(modulePath), escapeModulePath(version)
+
+	content, err := p.readModCache(escapedPath, escapedVersion+".zip")
+	if err != nil {
+		content, err = p.fetch(ctx, modulePath, fmt.Sprintf("%s/@v/%s.zip", escapedPath, escapedVersion))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+}
+
+func (p *ProxyClient) Versions(ctx context.Context, modulePath string) ([]string, error) {
+	content, err := p.fetch(ctx, modulePath, fmt.Sprintf("%s/@v/list", escapeModulePath(modulePath)))
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(content)), nil
+}
+
+func (p *ProxyClient) Info(ctx context.Context, modulePath string, version string) (*ModuleInfo, error) {
+	escapedPath, escapedVersion := escapeModulePath(modulePath), escapeModulePath(version)
+
+	content, err := p.readModCache(escapedPath, escapedVersion+".info")
+	if err != nil {
+		content, err = p.fetch(ctx, modulePath, fmt.Sprintf("%s/@v/%s.info", escapedPath, escapedVersion))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var info ModuleInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		return nil, fmt.Errorf("parsing info for %s@%s: %w", modulePath, version, err)
+	}
+
+	return &info, nil
+}
+
+func (p *ProxyClient) GoMod(ctx context.Context, modulePath string, version string) ([]byte, error) {
+	escapedPath, escapedVersion := escapeModulePath(modulePath), escapeModulePath(version)
+
+	content, err := p.readModCache(escapedPath, escapedVersion+".mod")
+	if err != nil {
+		return p.fetch(ctx, modulePath, fmt.Sprintf("%s/@v/%s.mod", escapedPath, escapedVersion))
+	}
+
+	return content, nil
+}
+
+func (p *ProxyClient) readModCache(escapedPath string, filename string) ([]byte, error) {
+	if p.ModCache == "" {
+		return nil, fmt.Errorf("no module cache configured")
+	}
+
+	return ioutil.ReadFile(filepath.Join(p.ModCache, "cache", "download", filepath.FromSlash(escapedPath), "@v", filename))
+}
+
+// IsPrivate reports whether modulePath matches GONOPROXY, so that it must not be looked up on the proxy.
+func (p *ProxyClient) IsPrivate(modulePath string) bool {
+	return matchesPathPatterns(p.NoProxy, modulePath)
+}
+
+func (p *ProxyClient) fetch(ctx context.Context, modulePath string, path string) ([]byte, error) {
+	if len(p.Proxies) == 0 {
+		return nil, fmt.Errorf("no module proxy configured")
+	}
+	if p.IsPrivate(modulePath) {
+		return nil, fmt.Errorf("%s matches GONOPROXY, so it isn't looked up on the module proxy", modulePath)
+	}
+
+	var lastErr error
+	for _, proxy := range p.Proxies {
+		content, status, err := p.get(ctx, proxy+"/"+path)
+		if err == nil {
+			return content, nil
+		}
+		lastErr = err
+		// Like the go command, only fall through to the next proxy when this one doesn't have the module.
+		if status != http.StatusNotFound && status != http.StatusGone {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (p *ProxyClient) get(ctx context.Context, rawURL string) ([]byte, int, error) {
+	req, err := newGetRequest(ctx, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	delay := p.RetryDelay
+	for attempt := 0; ; attempt++ {
+		content, status, err := p.do(req)
+		if err == nil || attempt >= p.Retries || !isRetryableStatus(status) {
+			return content, status, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, 0, err
+		}
+		delay *= 2
+	}
+}
+
+// isRetryableStatus reports whether a request that got status, or 0 when it got no response, may succeed later.
+func isRetryableStatus(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+func (p *ProxyClient) do(req *http.Request) ([]byte, int, error) {
+	if p.limiter != nil {
+		if err := p.limiter.Wait(req.Context()); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	rawURL := req.URL.String()
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make a request to %s: %w", rawURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, res.StatusCode, fmt.Errorf("unexpected status from %s: %d", rawURL, res.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, res.StatusCode, fmt.Errorf("reading response from %s: %w", rawURL, err)
+	}
+
+	return content, res.StatusCode, nil
+}
+
+func parseGoProxy(goproxy string) []string {
+	if goproxy == "" {
+		goproxy = defaultGoProxy
+	}
+
+	var proxies []string
+	for _, proxy := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" || proxy == "direct" || proxy == "off" {
+			continue
+		}
+		proxies = append(proxies, strings.TrimSuffix(proxy, "/"))
+	}
+
+	return proxies
+}
+
+// escapeModulePath applies the module proxy case encoding, where each upper case letter becomes '!' and its
+// lower case equivalent.
+func escapeModulePath(path string) string {
+	var result strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			result.WriteRune('!')
+			result.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
+
+// modCacheDir is the module cache: GOMODCACHE, which go env only reports from Go 1.15, or else pkg/mod in the first
+// GOPATH entry.
+func modCacheDir(gomodcache string, gopath string) string {
+	if gomodcache != "" || gopath == "" {
+		return gomodcache
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// getGoEnv reads go env variables as the go command sees them when run in dir with the given environment overrides.
+func getGoEnv(ctx context.Context, executor Executor, dir string, overrides []string,
+	names ...string) (map[string]string, error) {
+	command := Command{Name: "go", Args: append([]string{"env"}, names...), Dir: dir, Env: overrides}
+	result, err := executor.Run(ctx, command)
+	if err != nil {
+		return nil, fmt.Errorf("reading go env: %w", err)
+	}
+
+	values := strings.Split(strings.TrimSuffix(result.Stdout, "\n"), "\n")
+	if len(values) != len(names) {
+		return nil, fmt.Errorf("expected %d values from go env, got %d", len(names), len(values))
+	}
+
+	env := map[string]string{}
+	for i, name := range names {
+		env[name] = values[i]
+	}
+
+	return env, nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EscapeModulePath_EscapesUpperCaseLetters(t *testing.T) {
+	assert.Equal(t, "github.com/!alec!aivazis/survey/v2", escapeModulePath("github.com/AlecAivazis/survey/v2"))
+}
+
+func Test_ParseGoProxy_SkipsDirectAndOff(t *testing.T) {
+	proxies := parseGoProxy("https://corp.example.com/,https://proxy.golang.org|direct,off")
+
+	assert.Equal(t, []string{"https://corp.example.com", "https://proxy.golang.org"}, proxies)
+}
+
+func Test_ParseGoProxy_DefaultsToGoProxy(t *testing.T) {
+	assert.Equal(t, []string{defaultGoProxy}, parseGoProxy(""))
+}
+
+func Test_Zip_PrefersLocalModuleCache(t *testing.T) {
+	modCache, err := ioutil.TempDir("", "gomo-modcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(modCache)
+
+	zipDir := filepath.Join(modCache, "cache", "download", "github.com", "!foo", "bar", "@v")
+	require.NoError(t, os.MkdirAll(zipDir, 0755))
+	content := newModuleZip(t, "github.com/Foo/bar@v1.0.0/", map[string]string{"CHANGELOG.md": "cached"})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(zipDir, "v1.0.0.zip"), content, 0644))
+
+	mockClient := NewMockHTTPClient()
+	p := NewProxyClient(mockClient, "", modCache)
+
+	zipReader, err := p.Zip(context.Background(), "github.com/Foo/bar", "v1.0.0")
+	require.NoError(t, err)
+
+	require.Len(t, zipReader.File, 1)
+	assert.Equal(t, "github.com/Foo/bar@v1.0.0/CHANGELOG.md", zipReader.File[0].Name)
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_Zip_DownloadsFromProxy(t *testing.T) {
+	content := newModuleZip(t, "github.com/Foo/bar@v1.0.0/", map[string]string{"CHANGELOG.md": "downloaded"})
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, string(content), nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "")
+
+	zipReader, err := p.Zip(context.Background(), "github.com/Foo/bar", "v1.0.0")
+	require.NoError(t, err)
+
+	require.Len(t, zipReader.File, 1)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://proxy.example.com/github.com/!foo/bar/@v/v1.0.0.zip", calls[0].URL.String())
+}
+
+func Test_Zip_FallsThroughToNextProxyWhenNotFound(t *testing.T) {
+	content := newModuleZip(t, "example.com/mod@v1.0.0/", map[string]string{"go.mod": "module example.com/mod"})
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(404, "not found", nil),
+		newMockResponse(200, string(content), nil),
+	)
+	p := NewProxyClient(mockClient, "https://first.example.com,https://second.example.com", "")
+
+	_, err := p.Zip(context.Background(), "example.com/mod", "v1.0.0")
+	require.NoError(t, err)
+
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "second.example.com", calls[1].URL.Host)
+}
+
+func Test_Zip_ReturnsErrorWithoutFallingThroughOnServerError(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(newMockResponse(500, "", nil))
+	p := NewProxyClient(mockClient, "https://first.example.com,https://second.example.com", "")
+
+	_, err := p.Zip(context.Background(), "example.com/mod", "v1.0.0")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "unexpected status from https://first.example.com/example.com/mod/@v/v1.0.0.zip: 500")
+	assert.Len(t, mockClient.GetCalls(), 1)
+}
+
+func Test_Versions_ListsVersionsFromProxy(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\nv1.1.0\n", nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "")
+
+	versions, err := p.Versions(context.Background(), "github.com/Foo/bar")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, versions)
+	assert.Equal(t, "https://proxy.example.com/github.com/!foo/bar/@v/list", mockClient.GetCalls()[0].URL.String())
+}
+
+func Test_Versions_SendsRequestsWithContext(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v1.0.0\n", nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := p.Versions(ctx, "example.com/module")
+	require.NoError(t, err)
+
+	assert.Equal(t, ctx, mockClient.GetCalls()[0].Context())
+}
+
+func Test_Versions_DoesNotAskProxyAboutPrivateModules(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "", WithNoProxy("corp.example.com"))
+
+	_, err := p.Versions(context.Background(), "corp.example.com/lib")
+
+	assert.EqualError(t, err, "corp.example.com/lib matches GONOPROXY, so it isn't looked up on the module proxy")
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_Zip_ReadsPrivateModulesFromModuleCache(t *testing.T) {
+	modCache, err := ioutil.TempDir("", "gomo-modcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(modCache)
+
+	zipDir := filepath.Join(modCache, "cache", "download", "corp.example.com", "lib", "@v")
+	require.NoError(t, os.MkdirAll(zipDir, 0755))
+	content := newModuleZip(t, "corp.example.com/lib@v1.0.0/", map[string]string{"CHANGELOG.md": "cached"})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(zipDir, "v1.0.0.zip"), content, 0644))
+
+	mockClient := NewMockHTTPClient()
+	p := NewProxyClient(mockClient, "https://proxy.example.com", modCache, WithNoProxy("corp.example.com"))
+
+	_, err = p.Zip(context.Background(), "corp.example.com/lib", "v1.0.0")
+	require.NoError(t, err)
+
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_Info_PrefersLocalModuleCache(t *testing.T) {
+	modCache, err := ioutil.TempDir("", "gomo-modcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(modCache)
+
+	infoDir := filepath.Join(modCache, "cache", "download", "example.com", "mod", "@v")
+	require.NoError(t, os.MkdirAll(infoDir, 0755))
+	info := `{"Version":"v1.0.0","Time":"2020-01-02T03:04:05Z"}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(infoDir, "v1.0.0.info"), []byte(info), 0644))
+
+	mockClient := NewMockHTTPClient()
+	p := NewProxyClient(mockClient, "", modCache)
+
+	result, err := p.Info(context.Background(), "example.com/mod", "v1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", result.Version)
+	assert.True(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Equal(result.Time))
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_Info_DownloadsFromProxy(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, `{"Version":"v1.0.0","Time":"2020-01-02T03:04:05Z"}`, nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "")
+
+	result, err := p.Info(context.Background(), "example.com/mod", "v1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", result.Version)
+	assert.Equal(t, "https://proxy.example.com/example.com/mod/@v/v1.0.0.info", mockClient.GetCalls()[0].URL.String())
+}
+
+func Test_Info_ReturnsErrorForInvalidInfo(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "not json", nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "")
+
+	_, err := p.Info(context.Background(), "example.com/mod", "v1.0.0")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "parsing info for example.com/mod@v1.0.0")
+}
+
+func Test_ModCacheDir_FallsBackToFirstGopathEntry(t *testing.T) {
+	assert.Equal(t, "/modcache", modCacheDir("/modcache", "/gopath"))
+	assert.Equal(t, filepath.Join("/first", "pkg", "mod"),
+		modCacheDir("", "/first"+string(os.PathListSeparator)+"/second"))
+	assert.Equal(t, "", modCacheDir("", ""))
+}
+
+func Test_GetGoEnv_ReturnsValuesByName(t *testing.T) {
+	mockExecutor := &MockExecutor{CommandOutput: "https://proxy.golang.org,direct\n/home/user/go/pkg/mod\n"}
+
+	env, err := getGoEnv(context.Background(), mockExecutor, "", nil, "GOPROXY", "GOMODCACHE")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"GOPROXY":    "https://proxy.golang.org,direct",
+		"GOMODCACHE": "/home/user/go/pkg/mod",
+	}, env)
+	assert.Equal(t, []RunCall{{Command: "go", Args: "env GOPROXY GOMODCACHE"}}, mockExecutor.RunCalls)
+}
+
+func Test_GetGoEnv_ReturnsErrorFromExecutor(t *testing.T) {
+	mockExecutor := &MockExecutor{RunError: fmt.Errorf("an-error-from-executor")}
+
+	_, err := getGoEnv(context.Background(), mockExecutor, "", nil, "GOPROXY")
+
+	assert.EqualError(t, err, "reading go env: an-error-from-executor")
+}
+
+func newModuleZip(t *testing.T, prefix string, files map[string]string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := writer.Create(prefix + name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func Test_Versions_RetriesWhenProxyIsUnavailable(t *testing.T) {
+	sleeps := givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(503, "", nil),
+		newMockResponse(429, "", nil),
+		newMockResponse(200, "v1.0.0\n", nil),
+	)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "", WithRetries(2, time.Second))
+
+	versions, err := p.Versions(context.Background(), "example.com/mod")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.0"}, versions)
+	assert.Len(t, mockClient.GetCalls(), 3)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+}
+
+func Test_Versions_ReturnsErrorWhenRetriesRunOut(t *testing.T) {
+	givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenErrorIsReturned(fmt.Errorf("connection refused"))
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "", WithRetries(2, time.Second))
+
+	_, err := p.Versions(context.Background(), "example.com/mod")
+
+	assert.EqualError(t, err,
+		"failed to make a request to https://proxy.example.com/example.com/mod/@v/list: connection refused")
+	assert.Len(t, mockClient.GetCalls(), 3)
+}
+
+func Test_Versions_DoesNotRetryMissingModules(t *testing.T) {
+	sleeps := givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(404, "not found", nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "", WithRetries(2, time.Second))
+
+	_, err := p.Versions(context.Background(), "example.com/mod")
+
+	assert.Error(t, err)
+	assert.Len(t, mockClient.GetCalls(), 1)
+	assert.Empty(t, *sleeps)
+}
+
+func Test_Versions_WaitsForRateLimit(t *testing.T) {
+	givenNow(t, time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC))
+	sleeps := givenSleep(t)
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "", nil)
+	p := NewProxyClient(mockClient, "https://proxy.example.com", "", WithRateLimit(10))
+
+	_, err := p.Versions(context.Background(), "example.com/mod")
+	require.NoError(t, err)
+	_, err = p.Versions(context.Background(), "example.com/other")
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Duration{100 * time.Millisecond}, *sleeps)
+}
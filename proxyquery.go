@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// addProxyUpdates finds the newest release of each module by querying the module proxy directly, several modules at a
+// time, which is much faster than go list -u for large builds. Modules matching GONOPROXY are left to go list, so that
+// the proxy never learns about them. Replaced modules are handled by addReplacementTargets.
+func (d *Discoverer) addProxyUpdates(ctx context.Context, modules []Module) ([]Module, error) {
+	if d.Proxy == nil {
+		return nil, fmt.Errorf("no module proxy configured")
+	}
+
+	var lookups []int
+	var private []string
+	for i, module := range modules {
+		switch {
+		case module.Replace != nil:
+		case d.Proxy.IsPrivate(module.Name):
+			private = append(private, module.Name)
+		default:
+			lookups = append(lookups, i)
+		}
+	}
+
+	d.Progress.Status("looking up versions on the module proxy", len(lookups))
+	failures := make([]error, len(lookups))
+	err := parallel(ctx, len(lookups), d.Workers, func(i int) {
+		defer d.Progress.Advance()
+		failures[i] = d.lookUpUpdate(ctx, &modules[lookups[i]])
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, failure := range failures {
+		if failure != nil {
+			d.Warnings = append(d.Warnings, failure.Error())
+		}
+	}
+
+	if len(private) > 0 {
+		d.Progress.Status("listing updates of private modules", 0)
+		updates, err := d.listUpdates(ctx, private)
+		if err != nil {
+			return nil, err
+		}
+		for i, module := range modules {
+			if update, ok := updates[module.Name]; ok {
+				modules[i].ToVersion, modules[i].ToTime, modules[i].Update = update.ToVersion, update.ToTime, update.Update
+			}
+		}
+	}
+
+	var result []Module
+	for _, module := range modules {
+		if d.mayHaveUpdates(module) {
+			result = append(result, module)
+		}
+	}
+
+	return result, nil
+}
+
+// lookUpUpdate sets the target of module to its newest stable version, like go list -u, except that retractions are
+// not taken into account.
+func (d *Discoverer) lookUpUpdate(ctx context.Context, module *Module) error {
+	versions, err := d.Proxy.Versions(ctx, module.Name)
+	if err != nil {
+		return fmt.Errorf("skipping %s, whose versions couldn't be listed: %s", module.Name, err)
+	}
+
+	newest := module.FromVersion
+	for _, v := range versions {
+		version, err := semver.NewVersion(v)
+		if err != nil || version.Prerelease() != "" || d.Config.IsExcluded(module.Name, v) {
+			continue
+		}
+		if version.GreaterThan(newest) {
+			newest = version
+		}
+	}
+	if newest == module.FromVersion {
+		return nil
+	}
+
+	info, err := d.Proxy.Info(ctx, module.Name, newest.Original())
+	if err != nil {
+		return fmt.Errorf("skipping %s, whose version %s couldn't be read: %s", module.Name, newest.Original(), err)
+	}
+
+	module.ToVersion = newest
+	module.ToTime = info.Time
+	module.Update = classifyUpdate(module.FromVersion, newest)
+	return nil
+}
+
+// listUpdates asks go list -u for the updates of the given modules, keyed by module path.
+func (d *Discoverer) listUpdates(ctx context.Context, paths []string) (map[string]Module, error) {
+	args := append([]string{"list", "-m", "-u", "-f", template}, paths...)
+	result, err := d.Executor.Run(ctx, d.command("go", args...))
+	if err != nil {
+		return nil, fmt.Errorf("listing updates of %s: %w", strings.Join(paths, ", "), err)
+	}
+
+	modules, err := d.parseModules(result.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("parsing updates: %w", err)
+	}
+
+	updates := map[string]Module{}
+	for _, module := range modules {
+		updates[module.Name] = module
+	}
+	return updates, nil
+}
+
+// matchesPathPatterns reports whether a prefix of modulePath matches one of a comma-separated list of glob patterns,
+// the way the go command matches GOPRIVATE and GONOPROXY.
+func matchesPathPatterns(patterns string, modulePath string) bool {
+	elements := strings.Split(modulePath, "/")
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSuffix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+
+		n := strings.Count(pattern, "/") + 1
+		if len(elements) < n {
+			continue
+		}
+		if matched, _ := path.Match(pattern, strings.Join(elements[:n], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetModules_QueriesProxyForUpdates(t *testing.T) {
+	proxy := newFakeProxy(t)
+	executor := &MockExecutor{CommandOutput: "==START==example.com/lib,v1.0.0,,,,==END==\n" +
+		"==START==example.com/other,v0.2.0,,,,==END==\n" +
+		"==START==example.com/missing,v1.0.0,,,,==END==\n"}
+	d := NewDiscoverer(
+		WithExecutor(executor),
+		WithProxy(NewProxyClient(http.DefaultClient, proxy.URL, "")),
+		WithProxyQueries(2),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "example.com/lib", modules[0].Name)
+	assert.Equal(t, semver.MustParse("v1.1.0"), modules[0].ToVersion)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), modules[0].ToTime)
+	assert.Equal(t, UpdateMinor, modules[0].Update)
+
+	require.Len(t, d.Warnings, 1)
+	assert.Contains(t, d.Warnings[0], "skipping example.com/missing, whose versions couldn't be listed: ")
+
+	require.Len(t, executor.RunCalls, 1)
+	assert.Equal(t, "list -m -f "+template+" all", executor.RunCalls[0].Args)
+}
+
+func Test_GetModules_ListsUpdatesOfNoProxyModulesWithGoList(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	executor := &MockExecutor{OutputsForArgs: map[string]string{
+		"list -m -f " + template + " all": "==START==corp.example.com/private/lib,v1.0.0,,,,==END==\n",
+		"list -m -u -f " + template + " corp.example.com/private/lib": "==START==corp.example.com/private/lib,v1.0.0,v1.2.0," +
+			",2020-01-01T00:00:00Z,==END==\n",
+	}}
+	d := NewDiscoverer(
+		WithExecutor(executor),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example.com", "", WithNoProxy("corp.example.com/private"))),
+		WithProxyQueries(2),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, semver.MustParse("v1.2.0"), modules[0].ToVersion)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), modules[0].ToTime)
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_GetModules_ReturnsErrorWhenQueryingWithoutProxy(t *testing.T) {
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/lib,v1.0.0,,,,==END==\n"}),
+		WithProxyQueries(2),
+	)
+
+	_, err := d.GetModules(context.Background())
+
+	assert.EqualError(t, err, "looking up updates: no module proxy configured")
+}
+
+func Test_MatchesPathPatterns_MatchesPrefixesLikeGoCommand(t *testing.T) {
+	patterns := "corp.example.com, *.internal.example.com/team,github.com/org/priv*"
+
+	assert.True(t, matchesPathPatterns(patterns, "corp.example.com"))
+	assert.True(t, matchesPathPatterns(patterns, "corp.example.com/lib/v2"))
+	assert.True(t, matchesPathPatterns(patterns, "git.internal.example.com/team/lib"))
+	assert.True(t, matchesPathPatterns(patterns, "github.com/org/private"))
+	assert.False(t, matchesPathPatterns(patterns, "corp.example.community/lib"))
+	assert.False(t, matchesPathPatterns(patterns, "git.internal.example.com/other/lib"))
+	assert.False(t, matchesPathPatterns(patterns, "github.com/org/public"))
+	assert.False(t, matchesPathPatterns("", "corp.example.com"))
+}
@@ -0,0 +1,25 @@
+package main
+
+import "context"
+
+type MockReleaseNotesProvider struct {
+	ReturnNotes string
+	ReturnError error
+	Calls       []Module
+}
+
+func (p *MockReleaseNotesProvider) ReleaseNotes(ctx context.Context, module Module) (string, error) {
+	p.Calls = append(p.Calls, module)
+	return p.ReturnNotes, p.ReturnError
+}
+
+type MockForgeProvider struct {
+	ReturnNotes string
+	ReturnError error
+	Calls       []Repository
+}
+
+func (p *MockForgeProvider) ReleaseNotes(ctx context.Context, repo Repository, module Module) (string, error) {
+	p.Calls = append(p.Calls, repo)
+	return p.ReturnNotes, p.ReturnError
+}
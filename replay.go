@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+)
+
+// Fixture holds the commands and HTTP requests of a gomo session, so that it can be replayed without the go command
+// or network access.
+type Fixture struct {
+	Commands []CommandRecording `json:"commands,omitempty"`
+	Requests []RequestRecording `json:"requests,omitempty"`
+
+	mu sync.Mutex
+}
+
+type CommandRecording struct {
+	Command string `json:"command"`
+	Stdout  string `json:"stdout,omitempty"`
+	Stderr  string `json:"stderr,omitempty"`
+	Exit    int    `json:"exitCode,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RequestRecording is a response to a request. Bodies that aren't valid UTF-8, such as module zips, are kept in
+// BodyBase64 instead of Body.
+type RequestRecording struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"bodyBase64,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture %s: %w", path, err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+
+	return &fixture, nil
+}
+
+func (f *Fixture) Save(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding fixture: %w", err)
+	}
+
+	return writeFileAtomic(path, append(content, '\n'))
+}
+
+type RecordingExecutor struct {
+	Executor Executor
+	Fixture  *Fixture
+}
+
+func NewRecordingExecutor(executor Executor, fixture *Fixture) *RecordingExecutor {
+	return &RecordingExecutor{
+		Executor: executor,
+		Fixture:  fixture,
+	}
+}
+
+func (e *RecordingExecutor) Run(ctx context.Context, command Command) (Result, error) {
+	result, err := e.Executor.Run(ctx, command)
+
+	recording := CommandRecording{
+		Command: command.String(),
+		Stdout:  result.Stdout,
+		Stderr:  result.Stderr,
+		Exit:    result.ExitCode,
+	}
+	if err != nil {
+		recording.Error = err.Error()
+	}
+
+	e.Fixture.mu.Lock()
+	e.Fixture.Commands = append(e.Fixture.Commands, recording)
+	e.Fixture.mu.Unlock()
+
+	return result, err
+}
+
+type RecordingHTTPClient struct {
+	Client  HTTPClient
+	Fixture *Fixture
+}
+
+func NewRecordingHTTPClient(client HTTPClient, fixture *Fixture) *RecordingHTTPClient {
+	return &RecordingHTTPClient{
+		Client:  client,
+		Fixture: fixture,
+	}
+}
+
+func (c *RecordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	recording := RequestRecording{Method: req.Method, URL: req.URL.String()}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		recording.Error = err.Error()
+		c.record(recording)
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response from %s: %w", recording.URL, err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	recording.Status = res.StatusCode
+	recording.Header = res.Header
+	if utf8.Valid(body) {
+		recording.Body = string(body)
+	} else {
+		recording.BodyBase64 = body
+	}
+	c.record(recording)
+
+	return res, nil
+}
+
+func (c *RecordingHTTPClient) record(recording RequestRecording) {
+	c.Fixture.mu.Lock()
+	defer c.Fixture.mu.Unlock()
+	c.Fixture.Requests = append(c.Fixture.Requests, recording)
+}
+
+// ReplayExecutor serves recorded results in place of running commands. A command that ran several times is replayed
+// in the recorded order, repeating the last result once they run out.
+type ReplayExecutor struct {
+	Fixture *Fixture
+	served  map[string]int
+}
+
+func NewReplayExecutor(fixture *Fixture) *ReplayExecutor {
+	return &ReplayExecutor{
+		Fixture: fixture,
+		served:  map[string]int{},
+	}
+}
+
+func (e *ReplayExecutor) Run(ctx context.Context, command Command) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	e.Fixture.mu.Lock()
+	defer e.Fixture.mu.Unlock()
+
+	key := command.String()
+	var matches []CommandRecording
+	for _, recording := range e.Fixture.Commands {
+		if recording.Command == key {
+			matches = append(matches, recording)
+		}
+	}
+	if len(matches) == 0 {
+		return Result{}, fmt.Errorf("no recording of command %q", key)
+	}
+
+	recording := matches[replayIndex(e.served, key, len(matches))]
+	result := Result{Stdout: recording.Stdout, Stderr: recording.Stderr, ExitCode: recording.Exit}
+	if recording.Error != "" {
+		return result, errors.New(recording.Error)
+	}
+	return result, nil
+}
+
+// ReplayHTTPClient serves recorded responses in place of making requests, matching them by method and URL.
+type ReplayHTTPClient struct {
+	Fixture *Fixture
+	served  map[string]int
+}
+
+func NewReplayHTTPClient(fixture *Fixture) *ReplayHTTPClient {
+	return &ReplayHTTPClient{
+		Fixture: fixture,
+		served:  map[string]int{},
+	}
+}
+
+func (c *ReplayHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.Fixture.mu.Lock()
+	defer c.Fixture.mu.Unlock()
+
+	key := req.Method + " " + req.URL.String()
+	var matches []RequestRecording
+	for _, recording := range c.Fixture.Requests {
+		if recording.Method+" "+recording.URL == key {
+			matches = append(matches, recording)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no recording of request %s", key)
+	}
+
+	recording := matches[replayIndex(c.served, key, len(matches))]
+	if recording.Error != "" {
+		return nil, errors.New(recording.Error)
+	}
+
+	body := []byte(recording.Body)
+	if recording.BodyBase64 != nil {
+		body = recording.BodyBase64
+	}
+	return &http.Response{
+		StatusCode: recording.Status,
+		Header:     recording.Header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func replayIndex(served map[string]int, key string, count int) int {
+	index := served[key]
+	if index >= count {
+		return count - 1
+	}
+	served[key] = index + 1
+	return index
+}
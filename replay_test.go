@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fixture_ReplaysRecordedCommandsInOrder(t *testing.T) {
+	fixture := &Fixture{}
+	mockExecutor := MockExecutor{CommandOutput: "first"}
+	recorder := NewRecordingExecutor(&mockExecutor, fixture)
+	command := Command{Name: "go", Args: []string{"env", "GOPROXY"}}
+
+	_, err := recorder.Run(context.Background(), command)
+	require.NoError(t, err)
+	mockExecutor.CommandOutput = "second"
+	_, err = recorder.Run(context.Background(), command)
+	require.NoError(t, err)
+	mockExecutor.RunError = fmt.Errorf("exit status 1")
+	_, err = recorder.Run(context.Background(), Command{Name: "go", Args: []string{"get", "example.com/a"}})
+	require.Error(t, err)
+
+	replayer := NewReplayExecutor(givenSavedFixture(t, fixture))
+
+	for _, want := range []string{"first", "second", "second"} {
+		result, err := replayer.Run(context.Background(), command)
+		require.NoError(t, err)
+		assert.Equal(t, want, result.Stdout)
+	}
+
+	_, err = replayer.Run(context.Background(), Command{Name: "go", Args: []string{"get", "example.com/a"}})
+	assert.EqualError(t, err, "exit status 1")
+
+	_, err = replayer.Run(context.Background(), Command{Name: "go", Args: []string{"mod", "tidy"}})
+	assert.EqualError(t, err, `no recording of command "go mod tidy"`)
+}
+
+func Test_Fixture_ReplaysRecordedResponses(t *testing.T) {
+	fixture := &Fixture{}
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, "v1.0.0\nv1.1.0\n", http.Header{"Etag": {`"abc"`}}),
+		newMockResponse(200, "PK\x03\x04\xff\x00", nil),
+	)
+	recorder := NewRecordingHTTPClient(mockClient, fixture)
+
+	for _, url := range []string{"https://proxy.example/a/@v/list", "https://proxy.example/a/@v/v1.1.0.zip"} {
+		req, err := newGetRequest(context.Background(), url, nil)
+		require.NoError(t, err)
+		res, err := recorder.Do(req)
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+	}
+
+	replayer := NewReplayHTTPClient(givenSavedFixture(t, fixture))
+
+	proxy := NewProxyClient(replayer, "https://proxy.example", "")
+	versions, err := proxy.Versions(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, versions)
+
+	content, err := proxy.fetch(context.Background(), "a", "a/@v/v1.1.0.zip")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("PK\x03\x04\xff\x00"), content)
+
+	_, err = proxy.fetch(context.Background(), "b", "b/@v/list")
+	assert.EqualError(t, err, "failed to make a request to https://proxy.example/b/@v/list: "+
+		"no recording of request GET https://proxy.example/b/@v/list")
+}
+
+func givenSavedFixture(t *testing.T, fixture *Fixture) *Fixture {
+	dir, err := ioutil.TempDir("", "gomo-fixture")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "fixture.json")
+	require.NoError(t, fixture.Save(path))
+
+	loaded, err := LoadFixture(path)
+	require.NoError(t, err)
+	return loaded
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	staticRepoHosts = map[string]Forge{
+		"github.com":    ForgeGithub,
+		"gitlab.com":    ForgeGitlab,
+		"bitbucket.org": ForgeBitbucket,
+	}
+	// GitLab is left out as its projects can be nested in subgroups, so it's asked for go-import meta tags like the go
+	// command does.
+	pathRootRepoHosts = map[string]bool{
+		"github.com":    true,
+		"bitbucket.org": true,
+	}
+	metaTagRegex       = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaAttributeRegex = regexp.MustCompile(`(?is)(\w+)\s*=\s*["']([^"']*)["']`)
+	majorVersionRegex  = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+type goImport struct {
+	Prefix   string
+	VCS      string
+	RepoRoot string
+}
+
+type RepoResolver struct {
+	HTTPClient HTTPClient
+	forges     map[string]Forge
+}
+
+func NewRepoResolver(client HTTPClient) *RepoResolver {
+	forges := map[string]Forge{}
+	for host, forge := range staticRepoHosts {
+		forges[host] = forge
+	}
+
+	return &RepoResolver{
+		HTTPClient: client,
+		forges:     forges,
+	}
+}
+
+func (r *RepoResolver) Resolve(ctx context.Context, modulePath string) (Repository, error) {
+	root, repoURL, err := r.repoRoot(ctx, modulePath)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return Repository{}, fmt.Errorf("parsing repository url %q: %w", repoURL, err)
+	}
+
+	forge, err := r.detectForge(ctx, u.Host)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	return Repository{
+		Forge:  forge,
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git"),
+		Subdir: moduleSubdir(modulePath, root),
+	}, nil
+}
+
+func (r *RepoResolver) repoRoot(ctx context.Context, modulePath string) (string, string, error) {
+	elements := strings.Split(modulePath, "/")
+	if pathRootRepoHosts[elements[0]] {
+		if len(elements) < 3 {
+			return "", "", fmt.Errorf("unable to parse module name %q", modulePath)
+		}
+		root := strings.Join(elements[:3], "/")
+		return root, "https://" + root, nil
+	}
+
+	imports, err := r.fetchGoImports(ctx, modulePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	var match *goImport
+	for i, imp := range imports {
+		if imp.VCS != "git" {
+			continue
+		}
+		if modulePath != imp.Prefix && !strings.HasPrefix(modulePath, imp.Prefix+"/") {
+			continue
+		}
+		if match == nil || len(imp.Prefix) > len(match.Prefix) {
+			match = &imports[i]
+		}
+	}
+	if match == nil {
+		return "", "", fmt.Errorf("no go-import meta tag found for %q", modulePath)
+	}
+
+	return match.Prefix, match.RepoRoot, nil
+}
+
+func (r *RepoResolver) fetchGoImports(ctx context.Context, modulePath string) ([]goImport, error) {
+	req, err := newGetRequest(ctx, fmt.Sprintf("https://%s?go-get=1", modulePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := getText(r.HTTPClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching go-import meta tags: %w", err)
+	}
+
+	return parseGoImports(body), nil
+}
+
+func (r *RepoResolver) detectForge(ctx context.Context, host string) (Forge, error) {
+	if forge, ok := r.forges[host]; ok {
+		if forge == "" {
+			return "", fmt.Errorf("unable to detect the forge hosting %s", host)
+		}
+		return forge, nil
+	}
+
+	// GitLab only answers its version endpoint for authenticated users, but a 401 still identifies it.
+	probes := []struct {
+		forge    Forge
+		path     string
+		statuses []int
+	}{
+		{forge: ForgeGitea, path: "/api/v1/version", statuses: []int{http.StatusOK}},
+		{forge: ForgeGitlab, path: "/api/v4/version", statuses: []int{http.StatusOK, http.StatusUnauthorized}},
+	}
+	for _, probe := range probes {
+		if r.probe(ctx, host, probe.path, probe.statuses) {
+			r.forges[host] = probe.forge
+			return probe.forge, nil
+		}
+	}
+
+	r.forges[host] = ""
+	return "", fmt.Errorf("unable to detect the forge hosting %s", host)
+}
+
+func (r *RepoResolver) probe(ctx context.Context, host string, path string, statuses []int) bool {
+	req, err := newGetRequest(ctx, fmt.Sprintf("https://%s%s", host, path), nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+
+	for _, status := range statuses {
+		if res.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+func parseGoImports(body string) []goImport {
+	var imports []goImport
+	for _, tag := range metaTagRegex.FindAllString(body, -1) {
+		attributes := map[string]string{}
+		for _, attribute := range metaAttributeRegex.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(attribute[1])] = attribute[2]
+		}
+		if attributes["name"] != "go-import" {
+			continue
+		}
+
+		fields := strings.Fields(attributes["content"])
+		if len(fields) != 3 {
+			continue
+		}
+		imports = append(imports, goImport{Prefix: fields[0], VCS: fields[1], RepoRoot: fields[2]})
+	}
+
+	return imports
+}
+
+func moduleSubdir(modulePath string, root string) string {
+	subdir := strings.Trim(strings.TrimPrefix(modulePath, root), "/")
+	elements := strings.Split(subdir, "/")
+	if majorVersionRegex.MatchString(elements[len(elements)-1]) {
+		elements = elements[:len(elements)-1]
+	}
+
+	return strings.Join(elements, "/")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Resolve_ResolvesKnownHostsWithoutRequests(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	r := NewRepoResolver(mockClient)
+
+	repo, err := r.Resolve(context.Background(), "github.com/project/repo/sub/v2")
+	require.NoError(t, err)
+
+	assert.Equal(t, Repository{Forge: ForgeGithub, Host: "github.com", Path: "project/repo", Subdir: "sub"}, repo)
+	assert.Empty(t, mockClient.GetCalls())
+}
+
+func Test_Resolve_UsesGoImportMetaTagsForVanityImports(t *testing.T) {
+	page := `<html><head>
+<meta name="go-import" content="go.uber.org/zap git https://github.com/uber-go/zap">
+<meta name="go-source" content="go.uber.org/zap https://github.com/uber-go/zap https://github.com/uber-go/zap/tree/master{/dir} https://github.com/uber-go/zap/tree/master{/dir}/{file}#L{line}">
+</head></html>`
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, page, nil)
+	r := NewRepoResolver(mockClient)
+
+	repo, err := r.Resolve(context.Background(), "go.uber.org/zap")
+	require.NoError(t, err)
+
+	assert.Equal(t, Repository{Forge: ForgeGithub, Host: "github.com", Path: "uber-go/zap"}, repo)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://go.uber.org/zap?go-get=1", calls[0].URL.String())
+}
+
+func Test_Resolve_UsesGoImportMetaTagsForGitlabSubgroups(t *testing.T) {
+	page := `<meta name="go-import" content="gitlab.com/group/sub/project git https://gitlab.com/group/sub/project.git">`
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, page, nil)
+	r := NewRepoResolver(mockClient)
+
+	repo, err := r.Resolve(context.Background(), "gitlab.com/group/sub/project/v2")
+	require.NoError(t, err)
+
+	assert.Equal(t, Repository{Forge: ForgeGitlab, Host: "gitlab.com", Path: "group/sub/project"}, repo)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "https://gitlab.com/group/sub/project/v2?go-get=1", calls[0].URL.String())
+}
+
+func Test_Resolve_DetectsSelfHostedGitea(t *testing.T) {
+	page := `<meta name="go-import" content="git.example.com/team/lib git https://git.example.com/team/lib.git">`
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, page, nil),
+		newMockResponse(200, `{"version":"1.11.0"}`, nil),
+	)
+	r := NewRepoResolver(mockClient)
+
+	repo, err := r.Resolve(context.Background(), "git.example.com/team/lib")
+	require.NoError(t, err)
+
+	assert.Equal(t, Repository{Forge: ForgeGitea, Host: "git.example.com", Path: "team/lib"}, repo)
+	calls := mockClient.GetCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "https://git.example.com/api/v1/version", calls[1].URL.String())
+}
+
+func Test_Resolve_DetectsSelfHostedGitlab(t *testing.T) {
+	page := `<meta name="go-import" content="git.example.com/team/lib git https://git.example.com/team/lib.git">`
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, page, nil),
+		newMockResponse(404, "", nil),
+		newMockResponse(401, "", nil),
+	)
+	r := NewRepoResolver(mockClient)
+
+	repo, err := r.Resolve(context.Background(), "git.example.com/team/lib")
+	require.NoError(t, err)
+
+	assert.Equal(t, ForgeGitlab, repo.Forge)
+}
+
+func Test_Resolve_ReturnsErrorForUnknownForge(t *testing.T) {
+	page := `<meta name="go-import" content="golang.org/x/net git https://go.googlesource.com/net">`
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponsesAreReturnedInOrder(
+		newMockResponse(200, page, nil),
+		newMockResponse(404, "", nil),
+		newMockResponse(404, "", nil),
+	)
+	r := NewRepoResolver(mockClient)
+
+	_, err := r.Resolve(context.Background(), "golang.org/x/net")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "unable to detect the forge hosting go.googlesource.com")
+}
+
+func Test_Resolve_ReturnsErrorWhenNoMatchingMetaTag(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "<html></html>", nil)
+	r := NewRepoResolver(mockClient)
+
+	_, err := r.Resolve(context.Background(), "example.com/vanity")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), `no go-import meta tag found for "example.com/vanity"`)
+}
+
+func Test_ParseGoImports_ParsesMetaTags(t *testing.T) {
+	page := `<meta content="example.com/a git https://github.com/a/a" name="go-import"/>
+<meta name='go-import' content='example.com/b mod https://proxy.example.com'>
+<meta name="description" content="not an import">`
+
+	imports := parseGoImports(page)
+
+	assert.Equal(t, []goImport{
+		{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/a/a"},
+		{Prefix: "example.com/b", VCS: "mod", RepoRoot: "https://proxy.example.com"},
+	}, imports)
+}
@@ -0,0 +1,3 @@
+package other
+
+const Version = "v0.1.0-fork"
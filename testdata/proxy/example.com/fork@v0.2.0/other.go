@@ -0,0 +1,3 @@
+package other
+
+const Version = "v0.2.0-fork"
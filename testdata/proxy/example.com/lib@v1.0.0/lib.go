@@ -0,0 +1,5 @@
+package lib
+
+func Hello() string {
+	return "hello"
+}
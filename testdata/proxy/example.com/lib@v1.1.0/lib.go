@@ -0,0 +1,9 @@
+package lib
+
+func Hello() string {
+	return "hello!"
+}
+
+func Goodbye() string {
+	return "goodbye"
+}
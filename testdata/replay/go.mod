@@ -0,0 +1,8 @@
+module example.com/replay
+
+go 1.21
+
+require (
+	github.com/Masterminds/semver/v3 v3.0.3
+	github.com/fatih/color v1.7.0
+)
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	toolchainModule    = "golang.org/toolchain"
+	goDirective        = "go"
+	toolchainDirective = "toolchain"
+)
+
+var goVersionRegex = regexp.MustCompile(`^(?:go)?(\d+)\.(\d+)(?:\.(\d+))?(?:(rc|beta)(\d+))?$`)
+
+var toolchainVersionRegex = regexp.MustCompile(`^v0\.0\.1-(go[^.]+\.[^.]+(?:\.[^.]+)?)\.[^.]+-[^.]+$`)
+
+type GoReleaseSource interface {
+	GoReleases(ctx context.Context) ([]*semver.Version, error)
+}
+
+// ToolchainModuleSource lists Go releases from the versions of the golang.org/toolchain module, which the go command
+// downloads toolchains from.
+type ToolchainModuleSource struct {
+	Proxy *ProxyClient
+}
+
+func NewToolchainModuleSource(proxy *ProxyClient) *ToolchainModuleSource {
+	return &ToolchainModuleSource{
+		Proxy: proxy,
+	}
+}
+
+func (s *ToolchainModuleSource) GoReleases(ctx context.Context) ([]*semver.Version, error) {
+	versions, err := s.Proxy.Versions(ctx, toolchainModule)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s versions: %w", toolchainModule, err)
+	}
+
+	seen := map[string]bool{}
+	var releases []*semver.Version
+	for _, v := range versions {
+		matches := toolchainVersionRegex.FindStringSubmatch(v)
+		if matches == nil || seen[matches[1]] {
+			continue
+		}
+		seen[matches[1]] = true
+
+		if release, err := parseGoVersion(matches[1]); err == nil {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases, nil
+}
+
+type goDownload struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// GoDownloadsFeed lists Go releases from the go.dev/dl JSON feed, read from a URL or a local copy.
+type GoDownloadsFeed struct {
+	HTTPClient HTTPClient
+	Location   string
+}
+
+func NewGoDownloadsFeed(client HTTPClient, location string) *GoDownloadsFeed {
+	return &GoDownloadsFeed{
+		HTTPClient: client,
+		Location:   location,
+	}
+}
+
+func (f *GoDownloadsFeed) GoReleases(ctx context.Context) ([]*semver.Version, error) {
+	var downloads []goDownload
+	if strings.HasPrefix(f.Location, "http://") || strings.HasPrefix(f.Location, "https://") {
+		req, err := newGetRequest(ctx, f.Location, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := getJSON(f.HTTPClient, req, &downloads); err != nil {
+			return nil, err
+		}
+	} else {
+		content, err := ioutil.ReadFile(f.Location)
+		if err != nil {
+			return nil, fmt.Errorf("reading Go releases: %w", err)
+		}
+		if err := json.Unmarshal(content, &downloads); err != nil {
+			return nil, fmt.Errorf("parsing Go releases from %s: %w", f.Location, err)
+		}
+	}
+
+	var releases []*semver.Version
+	for _, download := range downloads {
+		if release, err := parseGoVersion(download.Version); err == nil {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases, nil
+}
+
+// parseGoVersion converts Go release names such as go1.21.0, 1.20 and 1.22rc1 to semantic versions.
+func parseGoVersion(version string) (*semver.Version, error) {
+	matches := goVersionRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid Go version %q", version)
+	}
+
+	patch := matches[3]
+	if patch == "" {
+		patch = "0"
+	}
+	normalised := fmt.Sprintf("%s.%s.%s", matches[1], matches[2], patch)
+	if matches[4] != "" {
+		normalised += fmt.Sprintf("-%s.%s", matches[4], matches[5])
+	}
+
+	return semver.NewVersion(normalised)
+}
+
+// formatGoVersion is the inverse of parseGoVersion, using the x.y form of releases before Go 1.21.
+func formatGoVersion(version *semver.Version) string {
+	result := fmt.Sprintf("%d.%d", version.Major(), version.Minor())
+	if version.Prerelease() != "" {
+		return result + strings.Replace(version.Prerelease(), ".", "", 1)
+	}
+	if version.Patch() > 0 || version.Major() > 1 || version.Minor() >= 21 {
+		result += "." + strconv.FormatUint(version.Patch(), 10)
+	}
+	return result
+}
+
+// directiveValue is the target of a go or toolchain directive upgrade as it's written in go.mod.
+func directiveValue(module Module) string {
+	if module.Directive == toolchainDirective {
+		return "go" + formatGoVersion(module.ToVersion)
+	}
+	return formatGoVersion(module.ToVersion)
+}
+
+func newestGoRelease(releases []*semver.Version, current *semver.Version) *semver.Version {
+	var newest *semver.Version
+	for _, release := range releases {
+		if release.Prerelease() != "" || !release.GreaterThan(current) {
+			continue
+		}
+		if newest == nil || release.GreaterThan(newest) {
+			newest = release
+		}
+	}
+
+	return newest
+}
+
+func (d *Discoverer) addGoDirectives(ctx context.Context, modules []Module) ([]Module, error) {
+	f, err := ReadModFile(d.ModFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Proxy != nil {
+		d.addRequiredGoVersions(ctx, modules, f)
+	}
+
+	if d.GoReleases == nil {
+		return modules, nil
+	}
+	targets, err := d.goDirectiveTargets(ctx, f)
+	if err != nil {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("skipping go directive upgrades: %s", err))
+		return modules, nil
+	}
+
+	return append(targets, modules...), nil
+}
+
+// goDirectiveTargets offers bumps of the go and toolchain directives of the main module to the newest Go release.
+func (d *Discoverer) goDirectiveTargets(ctx context.Context, f *ModFile) ([]Module, error) {
+	releases, err := d.GoReleases.GoReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Module
+	for _, directive := range []string{goDirective, toolchainDirective} {
+		value := f.Directive(directive)
+		if value == "" {
+			continue
+		}
+
+		current, err := parseGoVersion(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s directive: %w", directive, err)
+		}
+
+		newest := newestGoRelease(releases, current)
+		if newest == nil {
+			continue
+		}
+
+		result = append(result, Module{
+			Name:        directive,
+			FromVersion: current,
+			ToVersion:   newest,
+			Update:      classifyUpdate(current, newest),
+			Label:       directive + " directive",
+			Directive:   directive,
+			Changelog:   fmt.Sprintf("See https://go.dev/doc/go%d.%d for the release notes", newest.Major(), newest.Minor()),
+		})
+	}
+
+	return result, nil
+}
+
+// addRequiredGoVersions records the go directive of each target version that is newer than the main module's, as
+// upgrading to it would raise the main module's go directive too.
+func (d *Discoverer) addRequiredGoVersions(ctx context.Context, modules []Module, f *ModFile) {
+	mainVersion, err := parseGoVersion(f.Directive(goDirective))
+	if err != nil {
+		return
+	}
+
+	for i, module := range modules {
+		if module.Directive != "" || module.ToVersion == nil {
+			continue
+		}
+
+		content, err := d.Proxy.GoMod(ctx, module.SourcePath(), module.ToVersion.Original())
+		if err != nil {
+			continue
+		}
+
+		required := parseModFile(content).Directive(goDirective)
+		if version, err := parseGoVersion(required); err == nil && version.GreaterThan(mainVersion) {
+			modules[i].RequiresGo = required
+		}
+	}
+}
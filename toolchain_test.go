@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubGoReleaseSource []string
+
+func (s stubGoReleaseSource) GoReleases(ctx context.Context) ([]*semver.Version, error) {
+	var releases []*semver.Version
+	for _, release := range s {
+		releases = append(releases, semver.MustParse(release))
+	}
+	return releases, nil
+}
+
+func Test_ParseGoVersion_NormalisesGoReleaseNames(t *testing.T) {
+	tests := map[string]string{
+		"go1.21.0": "1.21.0",
+		"1.20":     "1.20.0",
+		"go1.22.3": "1.22.3",
+		"1.22rc1":  "1.22.0-rc.1",
+	}
+	for name, want := range tests {
+		version, err := parseGoVersion(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, version.String(), name)
+	}
+
+	_, err := parseGoVersion("devel")
+	assert.Error(t, err)
+}
+
+func Test_FormatGoVersion_UsesGoReleaseNames(t *testing.T) {
+	assert.Equal(t, "1.20", formatGoVersion(semver.MustParse("1.20.0")))
+	assert.Equal(t, "1.20.3", formatGoVersion(semver.MustParse("1.20.3")))
+	assert.Equal(t, "1.21.0", formatGoVersion(semver.MustParse("1.21.0")))
+	assert.Equal(t, "1.22rc1", formatGoVersion(semver.MustParse("1.22.0-rc.1")))
+}
+
+func Test_ToolchainModuleSource_ListsEachReleaseOnce(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "v0.0.1-go1.21.0.linux-amd64\nv0.0.1-go1.21.0.darwin-arm64\n"+
+		"v0.0.1-go1.22rc1.linux-amd64\nv0.0.1-go1.22.1.windows-386\n", nil)
+	source := NewToolchainModuleSource(NewProxyClient(mockClient, "https://proxy.example", ""))
+
+	releases, err := source.GoReleases(context.Background())
+	require.NoError(t, err)
+
+	var names []string
+	for _, release := range releases {
+		names = append(names, release.String())
+	}
+	assert.Equal(t, []string{"1.21.0", "1.22.0-rc.1", "1.22.1"}, names)
+	assert.Equal(t, "https://proxy.example/golang.org/toolchain/@v/list", mockClient.GetCalls()[0].URL.String())
+}
+
+func Test_GoDownloadsFeed_ReadsLocalCopy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomo-toolchain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dl.json")
+	content := `[{"version": "go1.22.1", "stable": true}, {"version": "go1.23rc1", "stable": false}]`
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	releases, err := NewGoDownloadsFeed(NewMockHTTPClient(), path).GoReleases(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, releases, 2)
+	assert.Equal(t, "1.22.1", releases[0].String())
+	assert.Equal(t, "1.23.0-rc.1", releases[1].String())
+}
+
+func Test_GoDownloadsFeed_FetchesURL(t *testing.T) {
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, `[{"version": "go1.22.1", "stable": true}]`, nil)
+
+	releases, err := NewGoDownloadsFeed(mockClient, "https://go.dev/dl/?mode=json").GoReleases(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, releases, 1)
+	assert.Equal(t, "1.22.1", releases[0].String())
+}
+
+func Test_GetModules_OffersGoAndToolchainDirectiveUpgrades(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n\ngo 1.21\n\ntoolchain go1.21.5\n")
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{}),
+		WithGoReleases(stubGoReleaseSource{"1.21.5", "1.22.2", "1.23.0-rc.1"}),
+		WithMainModFile(path),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 2)
+	assert.Equal(t, goDirective, modules[0].Directive)
+	assert.Equal(t, "1.21.0", modules[0].FromVersion.String())
+	assert.Equal(t, "1.22.2", modules[0].ToVersion.String())
+	assert.Equal(t, UpdateMinor, modules[0].Update)
+	assert.Equal(t, toolchainDirective, modules[1].Directive)
+	assert.Equal(t, "go1.22.2", directiveValue(modules[1]))
+}
+
+func Test_GetModules_FlagsTargetsRequiringNewerGo(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n\ngo 1.20\n")
+	mockClient := NewMockHTTPClient()
+	mockClient.GivenResponseIsReturned(200, "module example.com/a/module\n\ngo 1.22\n", nil)
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: "==START==example.com/a/module,v1.0.0,v1.1.0,,,==END=="}),
+		WithProxy(NewProxyClient(mockClient, "https://proxy.example", "")),
+		WithMainModFile(path),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 1)
+	assert.Equal(t, "1.22", modules[0].RequiresGo)
+	assert.Equal(t, "https://proxy.example/example.com/a/module/@v/v1.1.0.mod", mockClient.GetCalls()[0].URL.String())
+}
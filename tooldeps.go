@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	toolsInclude = "include"
+	toolsOnly    = "only"
+	toolsSkip    = "skip"
+)
+
+var toolsBuildTagRegex = regexp.MustCompile(`^//\s*(go:build|\+build)\s+tools\s*$`)
+
+// toolImports returns the packages imported by the files in dir that are only built with the tools tag, the
+// convention for tracking build tools before go.mod gained tool directives.
+func toolImports(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		if !hasToolsBuildTag(file.Comments, file.Package) {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				result = append(result, importPath)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func hasToolsBuildTag(comments []*ast.CommentGroup, packagePos token.Pos) bool {
+	for _, group := range comments {
+		if group.Pos() >= packagePos {
+			break
+		}
+		for _, comment := range group.List {
+			if toolsBuildTagRegex.MatchString(comment.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markToolDependencies flags the modules that provide the main module's tools, from both tool directives and tools
+// build tag files.
+func (d *Discoverer) markToolDependencies(modules []Module) error {
+	f, err := ReadModFile(d.ModFile)
+	if err != nil {
+		return err
+	}
+
+	packages, err := toolImports(filepath.Dir(d.ModFile))
+	if err != nil {
+		return err
+	}
+	packages = append(packages, f.Tools()...)
+
+	for _, pkg := range packages {
+		if i := providingModule(pkg, modules); i >= 0 {
+			modules[i].Tool = true
+		}
+	}
+
+	return nil
+}
+
+// dropIndirectModules drops indirect dependencies, except for the modules providing tool directive packages, as go get
+// -tool records those as indirect requirements.
+func (d *Discoverer) dropIndirectModules(modules []Module) ([]Module, error) {
+	var tools []string
+	if d.ModFile != "" {
+		f, err := ReadModFile(d.ModFile)
+		if err != nil {
+			return nil, err
+		}
+		tools = f.Tools()
+	}
+
+	keep := map[int]bool{}
+	for _, pkg := range tools {
+		if i := providingModule(pkg, modules); i >= 0 {
+			keep[i] = true
+		}
+	}
+
+	var result []Module
+	for i, module := range modules {
+		if !module.Indirect || keep[i] {
+			result = append(result, module)
+		}
+	}
+	return result, nil
+}
+
+// providingModule returns the index of the module that provides pkg, which is the one with the longest matching path,
+// or -1 when there is none.
+func providingModule(pkg string, modules []Module) int {
+	best := -1
+	for i, module := range modules {
+		if module.Directive != "" || (pkg != module.Name && !strings.HasPrefix(pkg, module.Name+"/")) {
+			continue
+		}
+		if best < 0 || len(module.Name) > len(modules[best].Name) {
+			best = i
+		}
+	}
+	return best
+}
+
+func filterTools(modules []Module, mode string) []Module {
+	if mode == toolsInclude {
+		return modules
+	}
+
+	var result []Module
+	for _, module := range modules {
+		if module.Tool == (mode == toolsOnly) {
+			result = append(result, module)
+		}
+	}
+	return result
+}
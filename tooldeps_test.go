@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToolsFile = `//go:build tools
+// +build tools
+
+package main
+
+import (
+	_ "github.com/golangci/golangci-lint/cmd/golangci-lint"
+	_ "gotest.tools/gotestsum"
+)
+`
+
+func Test_ToolImports_ReadsFilesWithToolsBuildTag(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n")
+	dir := filepath.Dir(path)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tools.go"), []byte(testToolsFile), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"),
+		[]byte("package main\n\nimport _ \"example.com/runtime\"\n"), 0644))
+
+	imports, err := toolImports(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"github.com/golangci/golangci-lint/cmd/golangci-lint", "gotest.tools/gotestsum"}, imports)
+}
+
+func Test_GetModules_MarksToolDependencies(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n\ngo 1.24\n\ntool example.com/generator/cmd/gen\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(filepath.Dir(path), "tools.go"), []byte(testToolsFile), 0644))
+	// go get -tool records the module providing a tool directive package as an indirect requirement.
+	output := "''\n'==START==example.com/generator,v1.0.0,v1.1.0,,,==END====INDIRECT=='\n" +
+		"'==START==example.com/generator-deps,v1.0.0,v1.2.0,,,==END====INDIRECT=='\n" +
+		"'==START==github.com/golangci/golangci-lint,v1.24.0,v1.25.0,,,==END=='\n" +
+		"'==START==example.com/runtime,v1.0.0,v1.0.1,,,==END=='\n"
+	d := NewDiscoverer(
+		WithExecutor(&MockExecutor{CommandOutput: output}),
+		WithMainModFile(path),
+	)
+
+	modules, err := d.GetModules(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, modules, 3)
+	assert.Equal(t, "example.com/generator", modules[0].Name)
+	assert.True(t, modules[0].Tool)
+	assert.True(t, modules[1].Tool)
+	assert.False(t, modules[2].Tool)
+}
+
+func Test_FilterTools_KeepsToolsOrOtherModules(t *testing.T) {
+	modules := []Module{{Name: "example.com/tool", Tool: true}, {Name: "example.com/runtime"}}
+
+	assert.Equal(t, modules, filterTools(modules, toolsInclude))
+	assert.Equal(t, modules[:1], filterTools(modules, toolsOnly))
+	assert.Equal(t, modules[1:], filterTools(modules, toolsSkip))
+}
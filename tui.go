@@ -0,0 +1,547 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/Masterminds/semver/v3"
+	"github.com/fatih/color"
+)
+
+const (
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+	tuiChromeLines        = 5
+)
+
+type tuiSortColumn int
+
+const (
+	sortByModule tuiSortColumn = iota
+	sortByCurrent
+	sortByTarget
+	sortByUpdateType
+	sortByAge
+	sortByFlags
+	numSortColumns
+)
+
+func (c tuiSortColumn) String() string {
+	switch c {
+	case sortByCurrent:
+		return "current"
+	case sortByTarget:
+		return "target"
+	case sortByUpdateType:
+		return "type"
+	case sortByAge:
+		return "age"
+	case sortByFlags:
+		return "flags"
+	default:
+		return "module"
+	}
+}
+
+// tuiModel holds the state of the full-screen prompt. It is driven purely by key presses so that it can be
+// tested without a terminal.
+type tuiModel struct {
+	modules     []Module
+	selected    []bool
+	visible     []int
+	cursor      int
+	offset      int
+	sortBy      tuiSortColumn
+	filter      []rune
+	searching   bool
+	excluded    map[int]string
+	reason      []rune
+	excluding   bool
+	confirmed   bool
+	cancelled   bool
+	interrupted bool
+}
+
+func newTUIModel(modules []Module) *tuiModel {
+	m := &tuiModel{
+		modules:  modules,
+		selected: make([]bool, len(modules)),
+		excluded: map[int]string{},
+	}
+	m.refresh()
+
+	return m
+}
+
+func (m *tuiModel) Done() bool {
+	return m.confirmed || m.cancelled || m.interrupted
+}
+
+func (m *tuiModel) Selected() []Module {
+	var result []Module
+	for i, mod := range m.modules {
+		if m.selected[i] {
+			result = append(result, mod)
+		}
+	}
+
+	return result
+}
+
+func (m *tuiModel) Excluded() []Exclusion {
+	var result []Exclusion
+	for i, mod := range m.modules {
+		if reason, ok := m.excluded[i]; ok {
+			result = append(result, Exclusion{Module: mod, Reason: reason})
+		}
+	}
+
+	return result
+}
+
+func (m *tuiModel) HandleKey(key rune) {
+	if m.searching {
+		m.handleSearchKey(key)
+		return
+	}
+	if m.excluding {
+		m.handleReasonKey(key)
+		return
+	}
+
+	switch key {
+	case terminal.KeyArrowUp, 'k':
+		m.moveCursor(-1)
+	case terminal.KeyArrowDown, 'j':
+		m.moveCursor(1)
+	case terminal.KeySpace:
+		if current, ok := m.current(); ok {
+			m.toggle(current)
+		}
+	case terminal.KeyEnter:
+		m.confirmed = true
+	case terminal.KeyInterrupt:
+		m.interrupted = true
+	case terminal.KeyEscape, 'q':
+		m.cancelled = true
+	case '/':
+		m.searching = true
+	case 's':
+		m.sortBy = (m.sortBy + 1) % numSortColumns
+		m.refresh()
+	case 'p':
+		m.selectVisible(func(mod Module) bool { return mod.Update == UpdatePatch })
+	case 'm':
+		m.selectVisible(func(mod Module) bool { return mod.Update == UpdateMinor })
+	case 't':
+		m.selectVisible(func(mod Module) bool { return mod.Tool })
+	case 'a':
+		m.selectVisible(func(Module) bool { return true })
+	case 'n':
+		for i := range m.selected {
+			m.selected[i] = false
+		}
+	case 'x':
+		if current, ok := m.current(); ok {
+			if _, excluded := m.excluded[current]; excluded {
+				delete(m.excluded, current)
+			} else if m.modules[current].Directive == "" {
+				m.excluding = true
+				m.reason = nil
+			}
+		}
+	}
+}
+
+func (m *tuiModel) handleReasonKey(key rune) {
+	switch key {
+	case terminal.KeyEnter:
+		m.excluding = false
+		if current, ok := m.current(); ok {
+			reason := strings.TrimSpace(string(m.reason))
+			if reason == "" {
+				reason = defaultExcludeReason
+			}
+			m.excluded[current] = reason
+			m.selected[current] = false
+		}
+	case terminal.KeyEscape:
+		m.excluding = false
+	case terminal.KeyInterrupt:
+		m.interrupted = true
+	case terminal.KeyBackspace, terminal.KeyDelete:
+		if len(m.reason) > 0 {
+			m.reason = m.reason[:len(m.reason)-1]
+		}
+	default:
+		if key >= ' ' {
+			m.reason = append(m.reason, key)
+		}
+	}
+}
+
+func (m *tuiModel) handleSearchKey(key rune) {
+	switch key {
+	case terminal.KeyEnter, terminal.KeyEscape:
+		m.searching = false
+	case terminal.KeyInterrupt:
+		m.interrupted = true
+	case terminal.KeyBackspace, terminal.KeyDelete:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+			m.refresh()
+		}
+	case terminal.KeyArrowUp:
+		m.moveCursor(-1)
+	case terminal.KeyArrowDown:
+		m.moveCursor(1)
+	default:
+		if key >= ' ' {
+			m.filter = append(m.filter, key)
+			m.refresh()
+		}
+	}
+}
+
+func (m *tuiModel) current() (int, bool) {
+	if len(m.visible) == 0 {
+		return 0, false
+	}
+	return m.visible[m.cursor], true
+}
+
+func (m *tuiModel) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// toggle selects or deselects a module, deselecting any other target of the same module as only one can be upgraded
+// to.
+func (m *tuiModel) toggle(index int) {
+	if _, excluded := m.excluded[index]; excluded {
+		return
+	}
+
+	m.selected[index] = !m.selected[index]
+	if !m.selected[index] {
+		return
+	}
+
+	for i, mod := range m.modules {
+		if i != index && mod.Name == m.modules[index].Name {
+			m.selected[i] = false
+		}
+	}
+}
+
+func (m *tuiModel) selectVisible(matches func(Module) bool) {
+	for _, i := range m.visible {
+		if matches(m.modules[i]) && !m.selected[i] {
+			m.toggle(i)
+		}
+	}
+}
+
+func (m *tuiModel) refresh() {
+	m.visible = m.visible[:0]
+	for i, mod := range m.modules {
+		if fuzzyMatch(string(m.filter), mod.DisplayName()) {
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	sort.SliceStable(m.visible, func(i, j int) bool {
+		a, b := m.modules[m.visible[i]], m.modules[m.visible[j]]
+		switch m.sortBy {
+		case sortByCurrent:
+			if c := compareVersions(a.FromVersion, b.FromVersion); c != 0 {
+				return c < 0
+			}
+		case sortByTarget:
+			if c := compareVersions(a.ToVersion, b.ToVersion); c != 0 {
+				return c < 0
+			}
+		case sortByUpdateType:
+			if a.Update != b.Update {
+				return a.Update < b.Update
+			}
+		case sortByAge:
+			// The newest releases come first, and those of unknown age last.
+			if !a.ToTime.Equal(b.ToTime) {
+				return !a.ToTime.IsZero() && (b.ToTime.IsZero() || a.ToTime.After(b.ToTime))
+			}
+		case sortByFlags:
+			if len(a.APIChanges) != len(b.APIChanges) {
+				return len(a.APIChanges) > len(b.APIChanges)
+			}
+		}
+		return a.Name < b.Name
+	})
+
+	m.moveCursor(0)
+}
+
+func (m *tuiModel) View(width int, height int) string {
+	tableHeight := (height - tuiChromeLines) / 2
+	if tableHeight < 3 {
+		tableHeight = 3
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+tableHeight {
+		m.offset = m.cursor - tableHeight + 1
+	}
+
+	nameWidth := 6
+	for _, mod := range m.modules {
+		if len(mod.DisplayName()) > nameWidth {
+			nameWidth = len(mod.DisplayName())
+		}
+	}
+	if limit := width - 64; nameWidth > limit && limit > 10 {
+		nameWidth = limit
+	}
+
+	header := "      " + tableRow(nameWidth, "MODULE", "CURRENT", "TARGET", "TYPE", "AGE", "FLAGS")
+	lines := []string{truncate(m.statusLine(), width), truncate(header, width)}
+	for row := m.offset; row < len(m.visible) && row < m.offset+tableHeight; row++ {
+		lines = append(lines, m.renderRow(row, nameWidth, width))
+	}
+	for row := len(m.visible) - m.offset; row < tableHeight; row++ {
+		lines = append(lines, "")
+	}
+
+	// Every line must fit on one row, or the frame outgrows the screen and scrolls on each redraw.
+	footer := wrapKeys(tuiKeys, width)
+	lines = append(lines, strings.Repeat("─", width))
+	lines = append(lines, m.detailLines(width, height-len(lines)-len(footer)-1)...)
+	lines = append(lines, strings.Repeat("─", width))
+	lines = append(lines, footer...)
+
+	return strings.Join(lines, "\n")
+}
+
+var tuiKeys = []string{"↑/↓ move", "space select", "/ search", "s sort", "p patches", "m minors", "t tools", "a all",
+	"n none", "x exclude", "enter confirm", "q quit"}
+
+// wrapKeys lays out the key help on as many lines of at most width as it takes.
+func wrapKeys(keys []string, width int) []string {
+	var lines []string
+	line := ""
+	for _, key := range keys {
+		if line != "" && utf8.RuneCountInString(line+"  "+key) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += "  "
+		}
+		line += truncate(key, width)
+	}
+
+	return append(lines, line)
+}
+
+func (m *tuiModel) statusLine() string {
+	if m.excluding {
+		return fmt.Sprintf("Why is this version bad? (enter to exclude it, esc to cancel): %s", string(m.reason))
+	}
+
+	status := fmt.Sprintf("Which modules do you want to upgrade? %d of %d selected, sorted by %s",
+		len(m.Selected()), len(m.modules), m.sortBy)
+	if m.searching || len(m.filter) > 0 {
+		status += fmt.Sprintf(", filter: /%s", string(m.filter))
+	}
+	return status
+}
+
+func (m *tuiModel) renderRow(row int, nameWidth int, width int) string {
+	index := m.visible[row]
+	mod := m.modules[index]
+
+	marker := "  "
+	if row == m.cursor {
+		marker = "> "
+	}
+	checkbox := "[ ] "
+	if m.selected[index] {
+		checkbox = "[x] "
+	}
+	if _, excluded := m.excluded[index]; excluded {
+		checkbox = "[!] "
+	}
+
+	line := tableRow(nameWidth, mod.DisplayName(), mod.FromVersion.String(), mod.ToVersion.String(), mod.Update.String(),
+		ageLabel(mod), flagsLabel(mod))
+	line = updateColor(mod.Update).Sprint(truncate(line, width-len(marker)-len(checkbox)))
+
+	return marker + checkbox + line
+}
+
+func (m *tuiModel) detailLines(width int, height int) []string {
+	if height < 1 {
+		height = 1
+	}
+
+	var lines []string
+	if current, ok := m.current(); ok {
+		mod := m.modules[current]
+		header := fmt.Sprintf("%s %s -> %s", mod.DisplayName(), mod.FromVersion, mod.ToVersion)
+		if mod.Label != "" {
+			header += fmt.Sprintf(" [%s]", mod.Label)
+		}
+		lines = append(lines, color.New(color.Bold).Sprint(truncate(header, width)))
+		if reason, ok := m.excluded[current]; ok {
+			lines = append(lines, color.New(color.FgRed).Sprint(truncate("excluding this version: "+reason, width)))
+		}
+		if mod.RequiresGo != "" {
+			lines = append(lines, color.New(color.FgYellow).Sprint(
+				truncate("upgrading raises the go directive to "+mod.RequiresGo, width)))
+		}
+		for _, change := range mod.APIChanges {
+			lines = append(lines, color.New(color.FgRed).Sprint(truncate("incompatible: "+change.String(), width)))
+		}
+		if mod.Changelog == "" {
+			lines = append(lines, "No release notes found")
+		} else {
+			for _, line := range strings.Split(mod.Changelog, "\n") {
+				lines = append(lines, truncate(line, width))
+			}
+		}
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return lines
+}
+
+func tableRow(nameWidth int, name, current, target, updateType, age, flags string) string {
+	if len(name) > nameWidth {
+		name = name[:nameWidth-1] + "…"
+	}
+	return fmt.Sprintf("%-*s  %-10s  %-10s  %-14s  %-10s  %s", nameWidth, name, current, target, updateType, age, flags)
+}
+
+// compareVersions orders versions like semver, with unknown versions first.
+func compareVersions(a, b *semver.Version) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(b)
+}
+
+func ageLabel(mod Module) string {
+	if mod.ToTime.IsZero() {
+		return "-"
+	}
+	return strings.TrimSuffix(releaseAge(mod.ToTime), " ago")
+}
+
+func flagsLabel(mod Module) string {
+	var flags []string
+	if mod.Tool {
+		flags = append(flags, "tool")
+	}
+	if len(mod.APIChanges) > 0 {
+		flags = append(flags, "breaking")
+	}
+	if mod.RequiresGo != "" {
+		flags = append(flags, "go"+mod.RequiresGo)
+	}
+	if mod.Changelog != "" {
+		flags = append(flags, "notes")
+	}
+	return strings.Join(flags, ",")
+}
+
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if width < 1 || len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func fuzzyMatch(pattern string, text string) bool {
+	text = strings.ToLower(text)
+	for _, r := range strings.ToLower(pattern) {
+		i := strings.IndexRune(text, r)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(string(r)):]
+	}
+
+	return true
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func runTUI(modules []Module) (Choices, error) {
+	stdio := terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+	reader := terminal.NewRuneReader(stdio)
+	if err := reader.SetTermMode(); err != nil {
+		return Choices{}, fmt.Errorf("setting terminal mode: %w", err)
+	}
+	defer func() {
+		_ = reader.RestoreTermMode()
+	}()
+
+	width, height := defaultTerminalWidth, defaultTerminalHeight
+	cursor := &terminal.Cursor{In: stdio.In, Out: stdio.Out}
+	if size, err := cursor.Size(reader.Buffer()); err == nil {
+		width, height = int(size.X), int(size.Y)
+	}
+
+	fmt.Fprint(stdio.Out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(stdio.Out, "\x1b[?25h\x1b[?1049l")
+
+	color.NoColor = false // https://github.com/golang/go/issues/18153
+	model := newTUIModel(modules)
+	for !model.Done() {
+		fmt.Fprint(stdio.Out, "\x1b[H\x1b[2J"+model.View(width, height))
+
+		key, _, err := reader.ReadRune()
+		if err != nil {
+			return Choices{}, err
+		}
+		model.HandleKey(key)
+	}
+
+	if model.interrupted {
+		return Choices{}, terminal.InterruptErr
+	}
+	if model.cancelled {
+		return Choices{}, nil
+	}
+	return Choices{Upgrades: model.Selected(), Exclusions: model.Excluded()}, nil
+}
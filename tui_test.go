@@ -0,0 +1,271 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TUIModel_TogglesHighlightedModule(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	m.HandleKey(terminal.KeyArrowDown)
+	m.HandleKey(terminal.KeySpace)
+	m.HandleKey(terminal.KeyEnter)
+
+	require.True(t, m.Done())
+	assert.Equal(t, []string{"github.com/b/minor"}, moduleNames(m.Selected()))
+}
+
+func Test_TUIModel_SelectsOneTargetPerModule(t *testing.T) {
+	modules := newTUIModules()
+	modules[1].Name = modules[0].Name
+	modules[1].Label = latestCommitLabel
+	m := newTUIModel(modules)
+
+	m.HandleKey(terminal.KeySpace)
+	m.HandleKey('j')
+	m.HandleKey(terminal.KeySpace)
+
+	require.Len(t, m.Selected(), 1)
+	assert.Equal(t, latestCommitLabel, m.Selected()[0].Label)
+}
+
+func Test_TUIModel_ExcludesHighlightedCandidateWithReason(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+	m.HandleKey(terminal.KeySpace)
+
+	for _, key := range "xpanics" {
+		m.HandleKey(key)
+	}
+	assert.Contains(t, m.statusLine(), "panics")
+	m.HandleKey(terminal.KeyEnter)
+
+	require.Len(t, m.Excluded(), 1)
+	assert.Equal(t, "github.com/a/patch", m.Excluded()[0].Module.Name)
+	assert.Equal(t, "panics", m.Excluded()[0].Reason)
+	assert.Empty(t, m.Selected())
+
+	m.HandleKey(terminal.KeySpace)
+	assert.Empty(t, m.Selected())
+
+	m.HandleKey('x')
+	assert.Empty(t, m.Excluded())
+}
+
+func Test_TUIModel_ExcludeUsesDefaultReasonAndCanBeCancelled(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	m.HandleKey('x')
+	m.HandleKey(terminal.KeyEscape)
+	assert.Empty(t, m.Excluded())
+	assert.False(t, m.Done())
+
+	m.HandleKey('x')
+	m.HandleKey(terminal.KeyEnter)
+	require.Len(t, m.Excluded(), 1)
+	assert.Equal(t, defaultExcludeReason, m.Excluded()[0].Reason)
+}
+
+func Test_TUIModel_SelectsAllPatches(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	m.HandleKey('p')
+
+	assert.Equal(t, []string{"github.com/a/patch", "github.com/c/patch"}, moduleNames(m.Selected()))
+}
+
+func Test_TUIModel_SelectsAllTools(t *testing.T) {
+	modules := newTUIModules()
+	modules[1].Tool = true
+	m := newTUIModel(modules)
+
+	m.HandleKey('t')
+
+	assert.Equal(t, []string{"github.com/b/minor"}, moduleNames(m.Selected()))
+}
+
+func Test_TUIModel_SelectsAllAndNone(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	m.HandleKey('a')
+	assert.Len(t, m.Selected(), 3)
+
+	m.HandleKey('n')
+	assert.Empty(t, m.Selected())
+}
+
+func Test_TUIModel_FiltersWithFuzzySearch(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	for _, key := range "//c/" {
+		m.HandleKey(key)
+	}
+	m.HandleKey(terminal.KeyEnter)
+	m.HandleKey('a')
+
+	assert.False(t, m.Done())
+	assert.Equal(t, []string{"github.com/c/patch"}, moduleNames(m.Selected()))
+}
+
+func Test_TUIModel_BackspaceWidensFilter(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	for _, key := range "/minorx" {
+		m.HandleKey(key)
+	}
+	assert.Empty(t, m.visible)
+
+	m.HandleKey(terminal.KeyDelete)
+	assert.Len(t, m.visible, 1)
+}
+
+func Test_TUIModel_SortsByColumn(t *testing.T) {
+	modules := newTUIModules()
+	modules[2].APIChanges = []APIChange{{Identifier: "Removed", Description: "removed func"}}
+	modules[0].ToTime = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	modules[2].ToTime = time.Date(2020, 6, 5, 0, 0, 0, 0, time.UTC)
+	m := newTUIModel(modules)
+
+	for _, tt := range []struct {
+		column   tuiSortColumn
+		expected []int
+	}{
+		{column: sortByCurrent, expected: []int{0, 1, 2}},
+		{column: sortByTarget, expected: []int{0, 1, 2}},
+		{column: sortByUpdateType, expected: []int{0, 2, 1}},
+		{column: sortByAge, expected: []int{2, 0, 1}},
+		{column: sortByFlags, expected: []int{2, 0, 1}},
+		{column: sortByModule, expected: []int{0, 1, 2}},
+	} {
+		m.HandleKey('s')
+		assert.Equal(t, tt.column, m.sortBy)
+		assert.Equal(t, tt.expected, m.visible, tt.column.String())
+	}
+}
+
+func Test_TUIModel_SortsByVersionsSemantically(t *testing.T) {
+	modules := newTUIModules()
+	modules[0].FromVersion = semver.MustParse("v1.10.0")
+	modules[0].ToVersion = semver.MustParse("v1.10.1")
+	modules[1].FromVersion = semver.MustParse("v1.9.0")
+	modules[1].ToVersion = semver.MustParse("v1.11.0")
+	m := newTUIModel(modules)
+
+	m.HandleKey('s')
+	assert.Equal(t, []int{1, 0, 2}, m.visible)
+
+	m.HandleKey('s')
+	assert.Equal(t, []int{0, 1, 2}, m.visible)
+}
+
+func Test_TUIModel_CancelAndInterrupt(t *testing.T) {
+	cancelled := newTUIModel(newTUIModules())
+	cancelled.HandleKey('q')
+	assert.True(t, cancelled.cancelled)
+
+	interrupted := newTUIModel(newTUIModules())
+	interrupted.HandleKey(terminal.KeyInterrupt)
+	assert.True(t, interrupted.interrupted)
+}
+
+func Test_TUIModel_CursorStaysInBounds(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	m.HandleKey(terminal.KeyArrowUp)
+	assert.Equal(t, 0, m.cursor)
+
+	for i := 0; i < 5; i++ {
+		m.HandleKey('j')
+	}
+	assert.Equal(t, 2, m.cursor)
+}
+
+func Test_TUIModel_ViewShowsTableAndDetails(t *testing.T) {
+	modules := newTUIModules()
+	modules[0].Changelog = "## v1.0.1\n- a fix"
+	m := newTUIModel(modules)
+	m.HandleKey(terminal.KeySpace)
+
+	view := m.View(100, 24)
+
+	assert.Contains(t, view, "1 of 3 selected, sorted by module")
+	assert.Contains(t, view, "> [x] ")
+	assert.Contains(t, view, "github.com/a/patch")
+	assert.Contains(t, view, "- a fix")
+	assert.Len(t, strings.Split(view, "\n"), 24)
+}
+
+var ansiEscapeRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func Test_TUIModel_ViewFitsNarrowTerminals(t *testing.T) {
+	m := newTUIModel(newTUIModules())
+
+	view := m.View(40, 24)
+
+	lines := strings.Split(view, "\n")
+	assert.Len(t, lines, 24)
+	for _, line := range lines {
+		line = ansiEscapeRegex.ReplaceAllString(line, "")
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), 40, line)
+	}
+	assert.Contains(t, view, "q quit")
+}
+
+func Test_FuzzyMatch_MatchesSubsequences(t *testing.T) {
+	assert.True(t, fuzzyMatch("gtfy", "github.com/stretchr/testify"))
+	assert.True(t, fuzzyMatch("", "anything"))
+	assert.False(t, fuzzyMatch("yfitset", "github.com/stretchr/testify"))
+}
+
+func newTUIModules() []Module {
+	return []Module{
+		{
+			Name:        "github.com/a/patch",
+			FromVersion: semver.MustParse("v1.0.0"),
+			ToVersion:   semver.MustParse("v1.0.1"),
+			Update:      UpdatePatch,
+		},
+		{
+			Name:        "github.com/b/minor",
+			FromVersion: semver.MustParse("v1.0.0"),
+			ToVersion:   semver.MustParse("v1.1.0"),
+			Update:      UpdateMinor,
+		},
+		{
+			Name:        "github.com/c/patch",
+			FromVersion: semver.MustParse("v2.0.0"),
+			ToVersion:   semver.MustParse("v2.0.1"),
+			Update:      UpdatePatch,
+		},
+	}
+}
+
+func moduleNames(modules []Module) []string {
+	var names []string
+	for _, mod := range modules {
+		names = append(names, mod.Name)
+	}
+	return names
+}
+
+func Test_TUIModel_DoesNotExcludeGoDirectives(t *testing.T) {
+	m := newTUIModel([]Module{{
+		Name:        goDirective,
+		FromVersion: semver.MustParse("1.21.0"),
+		ToVersion:   semver.MustParse("1.22.2"),
+		Directive:   goDirective,
+	}})
+
+	m.HandleKey('x')
+	m.HandleKey(terminal.KeyEnter)
+
+	assert.Empty(t, m.Excluded())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type UpdateKind int
+
+const (
+	UpdatePatch UpdateKind = iota
+	UpdateMinor
+	UpdateMajor
+	UpdatePrerelease
+	UpdatePseudoVersion
+	UpdateIncompatible
+	UpdateDowngrade
+	numUpdateKinds
+)
+
+// pseudoVersionRegex matches the prerelease part of vX.0.0-yyyymmddhhmmss-abcdefabcdef,
+// vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef and vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdefabcdef.
+var pseudoVersionRegex = regexp.MustCompile(`(^|\.)\d{14}-[0-9a-f]{12}$`)
+
+func (k UpdateKind) String() string {
+	switch k {
+	case UpdatePatch:
+		return "patch"
+	case UpdateMinor:
+		return "minor"
+	case UpdateMajor:
+		return "major"
+	case UpdatePrerelease:
+		return "prerelease"
+	case UpdatePseudoVersion:
+		return "pseudo-version"
+	case UpdateIncompatible:
+		return "+incompatible"
+	case UpdateDowngrade:
+		return "downgrade"
+	default:
+		return "unknown"
+	}
+}
+
+func parseUpdateKind(name string) (UpdateKind, bool) {
+	for kind := UpdateKind(0); kind < numUpdateKinds; kind++ {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+func classifyUpdate(from *semver.Version, to *semver.Version) UpdateKind {
+	switch {
+	case to.LessThan(from):
+		return UpdateDowngrade
+	case isPseudoVersion(to):
+		return UpdatePseudoVersion
+	case to.Metadata() == "incompatible":
+		return UpdateIncompatible
+	case to.Prerelease() != "":
+		return UpdatePrerelease
+	case to.Major() != from.Major():
+		return UpdateMajor
+	case to.Minor() != from.Minor():
+		return UpdateMinor
+	default:
+		return UpdatePatch
+	}
+}
+
+func isPseudoVersion(version *semver.Version) bool {
+	return pseudoVersionRegex.MatchString(version.Prerelease())
+}
+
+func pseudoVersionTime(version *semver.Version) (time.Time, bool) {
+	match := pseudoVersionRegex.FindString(version.Prerelease())
+	if match == "" {
+		return time.Time{}, false
+	}
+
+	commitTime, err := time.Parse("20060102150405", strings.TrimPrefix(match, ".")[:14])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return commitTime, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClassifyUpdate_ClassifiesVersionChanges(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want UpdateKind
+	}{
+		{"v1.2.3", "v1.2.4", UpdatePatch},
+		{"v1.2.9", "v1.3.0", UpdateMinor},
+		{"v1.0.0", "v1.1.1", UpdateMinor},
+		{"v1.9.3", "v2.0.0", UpdateMajor},
+		{"v0.1.0", "v1.0.0", UpdateMajor},
+		{"v1.2.3", "v1.3.0-rc.1", UpdatePrerelease},
+		{"v1.3.0-rc.1", "v1.3.0", UpdatePatch},
+		{"v0.0.0-20190101000000-abcdefabcdef", "v0.0.0-20200101000000-0123456789ab", UpdatePseudoVersion},
+		{"v1.2.3", "v1.2.4-0.20200101000000-0123456789ab", UpdatePseudoVersion},
+		{"v1.2.3", "v1.3.0-pre.0.20200101000000-0123456789ab", UpdatePseudoVersion},
+		{"v2.0.0+incompatible", "v3.0.0+incompatible", UpdateIncompatible},
+		{"v1.2.4", "v1.2.3", UpdateDowngrade},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			got := classifyUpdate(semver.MustParse(tt.from), semver.MustParse(tt.to))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_UpdateKind_String(t *testing.T) {
+	assert.Equal(t, "pseudo-version", UpdatePseudoVersion.String())
+	assert.Equal(t, "+incompatible", UpdateIncompatible.String())
+	assert.Equal(t, "unknown", numUpdateKinds.String())
+}
+
+func Test_ParseUpdateKind_ParsesKindNames(t *testing.T) {
+	kind, ok := parseUpdateKind("minor")
+	assert.True(t, ok)
+	assert.Equal(t, UpdateMinor, kind)
+
+	_, ok = parseUpdateKind("unknown")
+	assert.False(t, ok)
+}
+
+func Test_PseudoVersionTime_ParsesCommitTime(t *testing.T) {
+	for _, version := range []string{
+		"v0.0.0-20200102030405-abcdefabcdef",
+		"v1.2.4-0.20200102030405-abcdefabcdef",
+		"v1.3.0-pre.0.20200102030405-abcdefabcdef",
+	} {
+		commitTime, ok := pseudoVersionTime(semver.MustParse(version))
+		require.True(t, ok)
+
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), commitTime)
+	}
+
+	_, ok := pseudoVersionTime(semver.MustParse("v1.2.3"))
+	assert.False(t, ok)
+}
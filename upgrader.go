@@ -1,9 +1,21 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
 
 type Upgrader struct {
 	Executor Executor
+	Dir      string
+	Env      []string
+	ModFile  string
+	DryRun   bool
+	Tidy     bool
+	Output   io.Writer
 }
 
 type UpgraderOption func(*Upgrader)
@@ -11,6 +23,7 @@ type UpgraderOption func(*Upgrader)
 func NewUpgrader(options ...UpgraderOption) *Upgrader {
 	u := &Upgrader{
 		Executor: nil,
+		Output:   os.Stdout,
 	}
 
 	for _, option := range options {
@@ -26,20 +39,135 @@ func WithUpgradeExecutor(executor Executor) UpgraderOption {
 	}
 }
 
-func (u *Upgrader) UpgradeModules(modules []Module) error {
+// WithUpgradeDir runs the go command in dir, to upgrade the module there.
+func WithUpgradeDir(dir string) UpgraderOption {
+	return func(u *Upgrader) {
+		u.Dir = dir
+	}
+}
+
+// WithUpgradeEnv sets KEY=value overrides of the environment the go command runs with.
+func WithUpgradeEnv(env ...string) UpgraderOption {
+	return func(u *Upgrader) {
+		u.Env = env
+	}
+}
+
+// WithModFile makes the upgrader edit the given go.mod directly rather than running go get.
+func WithModFile(path string) UpgraderOption {
+	return func(u *Upgrader) {
+		u.ModFile = path
+	}
+}
+
+// WithDryRun makes the upgrader print the go.mod changes it would make instead of writing them.
+func WithDryRun(dryRun bool) UpgraderOption {
+	return func(u *Upgrader) {
+		u.DryRun = dryRun
+	}
+}
+
+// WithTidy makes the upgrader run go mod tidy after editing go.mod, to reconcile go.sum.
+func WithTidy(tidy bool) UpgraderOption {
+	return func(u *Upgrader) {
+		u.Tidy = tidy
+	}
+}
+
+func WithUpgradeOutput(output io.Writer) UpgraderOption {
+	return func(u *Upgrader) {
+		u.Output = output
+	}
+}
+
+func (u *Upgrader) UpgradeModules(ctx context.Context, modules []Module) error {
+	if u.ModFile != "" {
+		return u.editModFile(ctx, modules)
+	}
+
 	for _, mod := range modules {
-		if err := u.upgradeModule(mod); err != nil {
+		if err := u.upgradeModule(ctx, mod); err != nil {
 			return fmt.Errorf("upgrading module %q: %w", mod.Name, err)
 		}
 	}
 	return nil
 }
 
-func (u *Upgrader) upgradeModule(module Module) error {
-	_, err := u.Executor.Run("go", "get", module.Name)
+func (u *Upgrader) upgradeModule(ctx context.Context, module Module) error {
+	if module.Directive != "" {
+		_, err := u.Executor.Run(ctx, u.command("get", fmt.Sprintf("%s@%s", module.Directive, directiveValue(module))))
+		return err
+	}
+
+	if module.Replace != nil && module.ToVersion != nil {
+		replace := fmt.Sprintf("%s=%s@%s", module.Name, module.Replace.Path, module.ToVersion.Original())
+		if _, err := u.Executor.Run(ctx, u.command("mod", "edit", "-replace", replace)); err != nil {
+			return err
+		}
+		// go mod edit leaves go.sum alone, so the build would fail without the new replacement's checksums.
+		if _, err := u.Executor.Run(ctx, u.command("mod", "download", module.Name)); err != nil {
+			return fmt.Errorf("updating go.sum: %w", err)
+		}
+		return nil
+	}
+
+	target := module.Name
+	if module.ToVersion != nil {
+		target = fmt.Sprintf("%s@%s", module.Name, module.ToVersion.Original())
+	}
+
+	_, err := u.Executor.Run(ctx, u.command("get", target))
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (u *Upgrader) command(args ...string) Command {
+	return Command{Name: "go", Args: args, Dir: u.Dir, Env: u.Env}
+}
+
+func (u *Upgrader) editModFile(ctx context.Context, modules []Module) error {
+	f, err := ReadModFile(u.ModFile)
+	if err != nil {
+		return err
+	}
+	original := f.Bytes()
+
+	for _, mod := range modules {
+		if mod.ToVersion == nil {
+			return fmt.Errorf("upgrading module %q: unknown target version", mod.Name)
+		}
+		if mod.Directive != "" {
+			f.SetDirective(mod.Directive, directiveValue(mod))
+			continue
+		}
+		if mod.Replace != nil {
+			f.AddReplace(mod.Name, "", mod.Replace.Path, mod.ToVersion.Original())
+			continue
+		}
+		f.SetRequire(mod.Name, mod.ToVersion.Original())
+	}
+
+	if u.DryRun {
+		oldLines := strings.Split(strings.TrimSuffix(string(original), "\n"), "\n")
+		newLines := strings.Split(strings.TrimSuffix(string(f.Bytes()), "\n"), "\n")
+		for _, line := range diffLines(oldLines, newLines) {
+			fmt.Fprintln(u.Output, line)
+		}
+		return nil
+	}
+
+	if err := f.Write(); err != nil {
+		return err
+	}
+
+	if u.Tidy {
+		if _, err := u.Executor.Run(ctx, u.command("mod", "tidy")); err != nil {
+			return fmt.Errorf("tidying modules: %w", err)
+		}
+	}
+
+	return nil
+}
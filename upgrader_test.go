@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +21,7 @@ func Test_UpgradeReturnsErrorFromExecutor(t *testing.T) {
 		WithUpgradeExecutor(&mockExecutor),
 	)
 
-	err := u.UpgradeModules([]Module{{Name: "foo/bar"}})
+	err := u.UpgradeModules(context.Background(), []Module{{Name: "foo/bar"}})
 
 	assert.Contains(t, err.Error(), fmt.Sprintf(`upgrading module "foo/bar": %s`, wantError.Error()))
 }
@@ -30,7 +36,7 @@ func Test_UpgradeCallsExecutorRunWithTheCorrectArguments(t *testing.T) {
 	modules := []Module{
 		{Name: aModuleName},
 	}
-	err := u.UpgradeModules(modules)
+	err := u.UpgradeModules(context.Background(), modules)
 	require.NoError(t, err)
 
 	runCalls := mockExecutor.RunCalls
@@ -42,3 +48,143 @@ func Test_UpgradeCallsExecutorRunWithTheCorrectArguments(t *testing.T) {
 		Args:    args,
 	})
 }
+
+func Test_UpgradeRunsInDirWithEnv(t *testing.T) {
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(
+		WithUpgradeExecutor(&mockExecutor),
+		WithUpgradeDir("services/api"),
+		WithUpgradeEnv("GOPROXY=https://proxy.example"),
+	)
+
+	err := u.UpgradeModules(context.Background(), []Module{{Name: "foo/bar"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []RunCall{{
+		Command: "go",
+		Args:    "get foo/bar",
+		Dir:     "services/api",
+		Env:     []string{"GOPROXY=https://proxy.example"},
+	}}, mockExecutor.RunCalls)
+}
+
+func Test_UpgradePinsTheTargetVersion(t *testing.T) {
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(
+		WithUpgradeExecutor(&mockExecutor),
+	)
+
+	modules := []Module{
+		{Name: "frasercobb/gomo", ToVersion: semver.MustParse("v1.1.0")},
+	}
+	err := u.UpgradeModules(context.Background(), modules)
+	require.NoError(t, err)
+
+	runCalls := mockExecutor.RunCalls
+	require.Len(t, runCalls, 1)
+	assert.Equal(t, RunCall{Command: "go", Args: "get frasercobb/gomo@v1.1.0"}, runCalls[0])
+}
+
+func Test_UpgradeEditsReplaceDirectiveForReplacedModules(t *testing.T) {
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(
+		WithUpgradeExecutor(&mockExecutor),
+	)
+
+	modules := []Module{
+		{
+			Name:      "example.com/original",
+			ToVersion: semver.MustParse("v1.1.0"),
+			Replace:   &Replacement{Path: "example.com/fork", Version: semver.MustParse("v1.0.0")},
+		},
+	}
+	err := u.UpgradeModules(context.Background(), modules)
+	require.NoError(t, err)
+
+	assert.Equal(t, []RunCall{
+		{Command: "go", Args: "mod edit -replace example.com/original=example.com/fork@v1.1.0"},
+		{Command: "go", Args: "mod download example.com/original"},
+	}, mockExecutor.RunCalls)
+}
+
+func givenModFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "gomo-upgrader")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "go.mod")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func Test_UpgradeEditsModFileWithoutRunningGo(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n\nrequire example.com/a v1.0.0\n")
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(
+		WithUpgradeExecutor(&mockExecutor),
+		WithModFile(path),
+	)
+
+	err := u.UpgradeModules(context.Background(), []Module{{Name: "example.com/a", ToVersion: semver.MustParse("v1.1.0")}})
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/main\n\nrequire example.com/a v1.1.0\n", string(content))
+	assert.Empty(t, mockExecutor.RunCalls)
+}
+
+func Test_UpgradeRunsTidyAfterEditingModFile(t *testing.T) {
+	path := givenModFile(t, "module example.com/main\n\nrequire example.com/a v1.0.0\n")
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(
+		WithUpgradeExecutor(&mockExecutor),
+		WithModFile(path),
+		WithTidy(true),
+	)
+
+	err := u.UpgradeModules(context.Background(), []Module{{Name: "example.com/a", ToVersion: semver.MustParse("v1.1.0")}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []RunCall{{Command: "go", Args: "mod tidy"}}, mockExecutor.RunCalls)
+}
+
+func Test_UpgradeDryRunPrintsChangesWithoutWriting(t *testing.T) {
+	original := "module example.com/main\n\nrequire example.com/a v1.0.0\n"
+	path := givenModFile(t, original)
+	var output bytes.Buffer
+	u := NewUpgrader(
+		WithUpgradeExecutor(&MockExecutor{}),
+		WithModFile(path),
+		WithDryRun(true),
+		WithUpgradeOutput(&output),
+	)
+
+	err := u.UpgradeModules(context.Background(), []Module{{Name: "example.com/a", ToVersion: semver.MustParse("v1.1.0")}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "-require example.com/a v1.0.0\n+require example.com/a v1.1.0\n", output.String())
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+}
+
+func Test_UpgradeBumpsGoDirectives(t *testing.T) {
+	goBump := Module{Name: goDirective, Directive: goDirective, ToVersion: semver.MustParse("1.22.2")}
+	toolchainBump := Module{Name: toolchainDirective, Directive: toolchainDirective, ToVersion: semver.MustParse("1.22.2")}
+
+	mockExecutor := MockExecutor{}
+	u := NewUpgrader(WithUpgradeExecutor(&mockExecutor))
+	require.NoError(t, u.UpgradeModules(context.Background(), []Module{goBump, toolchainBump}))
+	assert.Equal(t, []RunCall{
+		{Command: "go", Args: "get go@1.22.2"},
+		{Command: "go", Args: "get toolchain@go1.22.2"},
+	}, mockExecutor.RunCalls)
+
+	path := givenModFile(t, "module example.com/main\n\ngo 1.21\n")
+	u = NewUpgrader(WithUpgradeExecutor(&MockExecutor{}), WithModFile(path))
+	require.NoError(t, u.UpgradeModules(context.Background(), []Module{goBump, toolchainBump}))
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/main\n\ngo 1.22.2\n\ntoolchain go1.22.2\n", string(content))
+}